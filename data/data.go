@@ -0,0 +1,16 @@
+// Package data holds the gameplay definitions designers edit. The files are
+// embedded as the defaults of a headless game.World, and the game reads them
+// from disk at startup so they can change without a rebuild.
+package data
+
+import "embed"
+
+const (
+	ArchetypesFile = "archetypes.json"
+	SpawnsFile     = "spawns.json"
+	CardsFile      = "cards.json"
+	StatusesFile   = "statuses.json"
+)
+
+//go:embed *.json
+var Files embed.FS
@@ -0,0 +1,172 @@
+package game
+
+import "fmt"
+
+// :enum AIState
+type AIState int
+
+const (
+	AI_IDLE    AIState = 0
+	AI_CHASE   AIState = 1
+	AI_WIND_UP AIState = 2
+	AI_ATTACK  AIState = 3
+	AI_RECOVER AIState = 4
+	AI_FLEE    AIState = 5
+)
+
+var aiStateNames = [...]string{
+	AI_IDLE:    "idle",
+	AI_CHASE:   "chase",
+	AI_WIND_UP: "wind up",
+	AI_ATTACK:  "attack",
+	AI_RECOVER: "recover",
+	AI_FLEE:    "flee",
+}
+
+func (state AIState) String() string {
+	if state >= 0 && int(state) < len(aiStateNames) {
+		return aiStateNames[state]
+	}
+	return fmt.Sprintf("AIState(%d)", int(state))
+}
+
+// AIConfig tunes the state machine of an enemy archetype, the "ai" object of
+// its entry in data/archetypes.json. Times are in seconds.
+//
+//	idle     until the player comes within AggroRadius
+//	chase    toward the player until within AttackRange, back to idle once
+//	         the player is LeashFactor times AggroRadius away
+//	wind up  standing still for WindUp, the player's chance to react, only
+//	         once the Cooldown since its last attack is over
+//	attack   spawns the Attack archetype at its first tick, for AttackTime
+//	recover  standing still for Recover, then chase again
+//	flee     away from the player once health drops to FleeHealth of its max,
+//	         until out of the leash
+type AIConfig struct {
+	AggroRadius float32 `json:"aggro_radius"`
+	AttackRange float32 `json:"attack_range"`
+	WindUp      float32 `json:"wind_up"`
+	AttackTime  float32 `json:"attack_time"`
+	Recover     float32 `json:"recover"`
+	// 0 never flees
+	FleeHealth float32 `json:"flee_health"`
+
+	Attack   string  `json:"attack"`
+	Cooldown float32 `json:"cooldown"`
+}
+
+const AI_LEASH_FACTOR float32 = 1.5
+
+func (config *AIConfig) validate() error {
+	if config.AggroRadius < 0 || config.AttackRange < 0 || config.WindUp < 0 || config.AttackTime < 0 || config.Recover < 0 || config.Cooldown < 0 {
+		return fmt.Errorf("ai times and distances can't be negative")
+	}
+	if config.FleeHealth < 0 || config.FleeHealth >= 1 {
+		return fmt.Errorf("ai flee_health is a part of max health, from 0 up to 1")
+	}
+	return nil
+}
+
+func (world *World) setAIState(en *Entity, state AIState) {
+	world.emit(Event{Type: EVENT_AI_TRANSITION, Entity: en.Handle, From: en.AIState, To: state})
+	en.AIState = state
+	en.AITimer = 0
+}
+
+// updateAI runs one tick of an enemy's state machine against target, moving
+// it while it chases or flees. An enemy not hostile to target, summoned by it
+// say, has nothing to fight and idles.
+func (world *World) updateAI(en *Entity, target *Entity, delta_t float32) {
+	var config *AIConfig = &en.AI
+	// a hit stunned enemy stands still with its timers held. Only the time in
+	// AI_ATTACK runs on, its attack went out at the first tick and mustn't go
+	// out again once the stun is over
+	if en.HitStunTimer > 0 {
+		if en.AIState == AI_ATTACK {
+			en.AITimer += delta_t
+		}
+		en.inputAxis = Vector2{}
+		return
+	}
+	en.AITimer += delta_t
+	en.AttackCooldown = max(en.AttackCooldown-delta_t, 0)
+
+	if world.Config.Relations.Of(en.Faction, target.Faction) != RELATION_HOSTILE {
+		if en.AIState != AI_IDLE {
+			world.setAIState(en, AI_IDLE)
+		}
+		en.inputAxis = Vector2{}
+		return
+	}
+
+	var toTarget Vector2 = Vector2Subtract(target.Position, en.Position)
+	var distance float32 = Vector2Length(toTarget)
+	var leash float32 = config.AggroRadius * AI_LEASH_FACTOR
+	var fleeing bool = en.MaxHealth > 0 && float32(en.Health) <= config.FleeHealth*float32(en.MaxHealth)
+
+	switch en.AIState {
+	case AI_IDLE:
+		if distance <= config.AggroRadius {
+			world.setAIState(en, AI_CHASE)
+		}
+
+	case AI_CHASE:
+		if fleeing {
+			world.setAIState(en, AI_FLEE)
+		} else if distance > leash {
+			world.setAIState(en, AI_IDLE)
+		} else if distance <= config.AttackRange && en.AttackCooldown <= 0 {
+			world.setAIState(en, AI_WIND_UP)
+		}
+
+	case AI_WIND_UP:
+		if en.AITimer >= config.WindUp {
+			world.setAIState(en, AI_ATTACK)
+		}
+
+	case AI_ATTACK:
+		if en.AITimer >= config.AttackTime {
+			world.setAIState(en, AI_RECOVER)
+		}
+
+	case AI_RECOVER:
+		if en.AITimer >= config.Recover {
+			world.setAIState(en, AI_CHASE)
+		}
+
+	case AI_FLEE:
+		if distance > leash {
+			world.setAIState(en, AI_IDLE)
+		}
+	}
+
+	// :ai movement
+	en.inputAxis = Vector2{}
+	switch en.AIState {
+	case AI_CHASE:
+		// waiting out the cooldown within reach instead of walking into the target
+		if distance > config.AttackRange {
+			en.inputAxis = Vector2Normalize(toTarget)
+		}
+	case AI_FLEE:
+		en.inputAxis = Vector2Scale(Vector2Normalize(toTarget), -1)
+	}
+	en.Position = Vector2Add(en.Position, Vector2Scale(en.inputAxis, float32(en.Speed)*delta_t*world.speedMultiplier(en)))
+}
+
+// enemyAttack spawns the attack of en aimed at target. Like a card does for
+// its projectiles, an enemy with a Damage of its own overrides the attack's.
+func (world *World) enemyAttack(en *Entity, target *Entity) {
+	en.AttackCooldown = en.AI.Cooldown
+	if en.AI.Attack == "" {
+		return
+	}
+
+	attack, err := world.spawnAttack(en.AI.Attack, en, target.Position)
+	if err != nil {
+		return
+	}
+	if en.Damage > 0 {
+		attack.Damage = en.Damage
+	}
+}
@@ -0,0 +1,73 @@
+package game
+
+import (
+	"slices"
+	"testing"
+)
+
+// aiWorld is a test world holding one enemy of the given archetype.
+func aiWorld(t *testing.T, archetype string, position Vector2) (*World, *Entity) {
+	world, _ := testWorld()
+	return world, spawnAt(t, world, archetype, position)
+}
+
+// stepAI runs headless ticks and collects the states the enemy goes through.
+func stepAI(world *World, enemy *Entity, ticks int) []AIState {
+	var states []AIState = nil
+	stepEvents(world, ticks, InputState{}, func(event Event) {
+		if event.Type == EVENT_AI_TRANSITION && event.Entity == enemy.Handle {
+			states = append(states, event.To)
+		}
+	})
+	return states
+}
+
+func TestAIChasesWindsUpAndAttacks(t *testing.T) {
+	world, goblin := aiWorld(t, "goblin", Vector2{X: 60})
+	var states []AIState = stepAI(world, goblin, 240)
+
+	var expected []AIState = []AIState{AI_CHASE, AI_WIND_UP, AI_ATTACK, AI_RECOVER, AI_CHASE}
+	if len(states) < len(expected) || !slices.Equal(states[:len(expected)], expected) {
+		t.Fatalf("goblin went through %v, expected it to start with %v", states, expected)
+	}
+}
+
+func TestAIStaysIdleOutOfAggro(t *testing.T) {
+	world, goblin := aiWorld(t, "goblin", Vector2{X: 1000})
+	if states := stepAI(world, goblin, 60); len(states) != 0 || goblin.AIState != AI_IDLE {
+		t.Fatalf("goblin out of aggro went through %v", states)
+	}
+	if goblin.Position != (Vector2{X: 1000}) {
+		t.Fatalf("idle goblin moved to %v", goblin.Position)
+	}
+}
+
+func TestAIFleesAtLowHealth(t *testing.T) {
+	world, goblin := aiWorld(t, "goblin", Vector2{X: 60})
+	goblin.Health = 2
+	var states []AIState = stepAI(world, goblin, 120)
+
+	if len(states) < 2 || !slices.Equal(states[:2], []AIState{AI_CHASE, AI_FLEE}) {
+		t.Fatalf("hurt goblin went through %v, expected chase then flee", states)
+	}
+	if goblin.Position.X <= 60 {
+		t.Fatalf("fleeing goblin at %v didn't move away from the player", goblin.Position)
+	}
+}
+
+func TestAIAttacksHurtOnlyThePlayer(t *testing.T) {
+	for _, archetype := range []string{"goblin", "troll"} {
+		world, _ := aiWorld(t, archetype, Vector2{X: 60})
+		bystander, _ := world.spawnArchetype("goblin", Vector2{X: 70, Y: 3})
+		bystander.AI = AIConfig{}
+		playerEntity, _ := world.Get(world.Player)
+
+		stepAI(world, bystander, 180)
+		if playerEntity.Health >= playerEntity.MaxHealth {
+			t.Fatalf("%s never hurt the player", archetype)
+		}
+		if bystander.Health != bystander.MaxHealth {
+			t.Fatalf("%s hurt another enemy, health %d", archetype, bystander.Health)
+		}
+	}
+}
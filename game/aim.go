@@ -0,0 +1,117 @@
+package game
+
+import "math"
+
+// :enum AimShape
+type AimShape int
+
+const (
+	AIM_NONE   AimShape = 0
+	AIM_LINE   AimShape = 1
+	AIM_CIRCLE AimShape = 2
+	AIM_CONE   AimShape = 3
+)
+
+// AimPreview is what playing the grabbed card would cover, in world units,
+// for the renderer to draw while the card is dragged:
+//
+//	AIM_LINE    a band of Width from Origin toward Target, Range long, ending
+//	            in a blast of Radius when the projectile detonates
+//	AIM_CIRCLE  a circle of Width radius around Target
+//	AIM_CONE    a sector of Range radius from Origin toward Target, Width
+//	            degrees wide
+//
+// Target is already clamped to the card's Range. Valid is false when the
+// card can't be played right now, it would be refused on release.
+type AimPreview struct {
+	Shape  AimShape
+	Origin Vector2
+	Target Vector2
+	Range  float32
+	Width  float32
+	Radius float32
+	Valid  bool
+}
+
+// aimTarget clamps mouseWorld to the card's range around the caster. A card
+// without a Range reaches anywhere.
+func aimTarget(card *Entity, caster *Entity, mouseWorld Vector2) Vector2 {
+	var toMouse Vector2 = Vector2Subtract(mouseWorld, caster.Position)
+	if card.Range > 0 && Vector2Length(toMouse) > float32(card.Range) {
+		return Vector2Add(caster.Position, Vector2Scale(Vector2Normalize(toMouse), float32(card.Range)))
+	}
+	return mouseWorld
+}
+
+// AimPreview describes the aim of the grabbed card while it is dragged above
+// the hand area, the shape comes from the card's first effect that is aimed.
+func (world *World) AimPreview(mouseWorld Vector2) (AimPreview, bool) {
+	card, ok := world.Hand.Get(world.GrabbedCard)
+	if !ok || card.Position.Y >= world.HandAreaTop() {
+		return AimPreview{}, false
+	}
+	caster, ok := world.Get(world.Player)
+	if !ok {
+		return AimPreview{}, false
+	}
+	definition, ok := world.Config.Cards[card.Card]
+	if !ok {
+		return AimPreview{}, false
+	}
+
+	_, blocked := world.castingBlockedBy(caster)
+	var preview AimPreview = AimPreview{
+		Origin: caster.Position,
+		Target: aimTarget(card, caster, mouseWorld),
+		Range:  float32(card.Range),
+		Valid:  world.CanAfford(card, caster) && !blocked,
+	}
+
+	for i := 0; i < len(definition.Effects) && preview.Shape == AIM_NONE; i++ {
+		var effect *Effect = &definition.Effects[i]
+		switch effect.Kind {
+		case EFFECT_PROJECTILE:
+			var attack Archetype = world.Config.Archetypes[effect.Archetype]
+			if preview.Range == 0 {
+				preview.Range = float32(attack.Range)
+			}
+			if attack.Melee {
+				preview.Shape = AIM_CONE
+				preview.Width = attack.MaxAngle
+				continue
+			}
+			preview.Shape = AIM_LINE
+			preview.Width = float32(getSpriteSize(attack.SpriteId).Height)
+			if attack.DetonateOnImpact || attack.DetonateAtMaxRange {
+				preview.Radius = float32(attack.Width)
+				if card.Width > 0 {
+					preview.Radius = float32(card.Width)
+				}
+			}
+		case EFFECT_AREA_DAMAGE, EFFECT_SUMMON:
+			preview.Shape = AIM_CIRCLE
+			preview.Width = max(effect.Radius, float32(TILE_WIDTH))
+		case EFFECT_STATUS:
+			if effect.Radius > 0 {
+				preview.Shape = AIM_CIRCLE
+				preview.Width = effect.Radius
+			}
+		case EFFECT_DASH:
+			preview.Shape = AIM_LINE
+			preview.Range = min(effect.Distance, Vector2Distance(caster.Position, preview.Target))
+			preview.Width = float32(getSpriteSize(caster.SpriteId).Width)
+		}
+	}
+
+	if preview.Shape == AIM_NONE {
+		return AimPreview{}, false
+	}
+	return preview, true
+}
+
+// Angle is the direction from Origin to Target in degrees, as raylib draws
+// rotations.
+func (preview AimPreview) Angle() float32 {
+	var direction Vector2 = Vector2Subtract(preview.Target, preview.Origin)
+	return float32(math.Atan2(float64(direction.Y), float64(direction.X)) * 180 / math.Pi)
+}
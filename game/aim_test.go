@@ -0,0 +1,19 @@
+package game
+
+import "testing"
+
+func TestAimPreviewInvalidWhileCastingIsBlocked(t *testing.T) {
+	world, playerEntity := testWorld()
+	var card *Entity = firstCard(t, world)
+	world.GrabbedCard = card.Handle
+	card.Position = Vector2{X: 400, Y: 100}
+	playerEntity.Mana = float32(playerEntity.MaxMana)
+
+	if preview, ok := world.AimPreview(Vector2{X: 50}); !ok || !preview.Valid {
+		t.Fatalf("a card the player can afford previews as %+v", preview)
+	}
+	world.applyStatus(playerEntity, "stun", 0)
+	if preview, ok := world.AimPreview(Vector2{X: 50}); !ok || preview.Valid {
+		t.Fatal("the aim of a stunned player previews as valid")
+	}
+}
@@ -0,0 +1,70 @@
+package game
+
+import "errors"
+
+var (
+	ErrWorldFull = errors.New("max # of entities reached")
+	ErrHandFull  = errors.New("max # of cards in hand reached")
+)
+
+// :enum OverflowPolicy
+// what createEntity does when every slot in World.Entities is taken
+type OverflowPolicy int
+
+const (
+	OVERFLOW_REFUSE                OverflowPolicy = 0
+	OVERFLOW_RECYCLE_OLDEST_ATTACK OverflowPolicy = 1
+)
+
+type AllocatorStats struct {
+	Live          int32
+	HighWaterMark int32
+	Capacity      int32
+	Refused       int32
+	Recycled      int32
+}
+
+// slotAllocator hands out slot indices of a fixed size array in O(1) through a
+// stack of free indices. Slots are handed out lowest index first until the
+// first release, after that the most recently freed slot is reused first.
+type slotAllocator struct {
+	free        []int32
+	generations []uint32
+	stats       AllocatorStats
+}
+
+func newSlotAllocator(capacity int32) slotAllocator {
+	var allocator slotAllocator = slotAllocator{
+		free:        make([]int32, 0, capacity),
+		generations: make([]uint32, capacity),
+	}
+	allocator.stats.Capacity = capacity
+
+	for i := capacity - 1; i >= 0; i-- {
+		allocator.free = append(allocator.free, i)
+	}
+	return allocator
+}
+
+func (allocator *slotAllocator) alloc() (EntityHandle, bool) {
+	var count int = len(allocator.free)
+	if count == 0 {
+		return EntityHandle{}, false
+	}
+
+	var index int32 = allocator.free[count-1]
+	allocator.free = allocator.free[:count-1]
+	allocator.generations[index] += 1
+
+	allocator.stats.Live += 1
+	if allocator.stats.Live > allocator.stats.HighWaterMark {
+		allocator.stats.HighWaterMark = allocator.stats.Live
+	}
+
+	return EntityHandle{Index: index, Generation: allocator.generations[index]}, true
+}
+
+func (allocator *slotAllocator) release(index int32) {
+	allocator.free = append(allocator.free, index)
+	allocator.stats.Live -= 1
+}
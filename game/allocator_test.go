@@ -0,0 +1,137 @@
+package game
+
+import "testing"
+
+func TestAllocatorReusesTheLastFreedSlot(t *testing.T) {
+	var allocator slotAllocator = newSlotAllocator(4)
+	first, _ := allocator.alloc()
+	second, _ := allocator.alloc()
+	third, _ := allocator.alloc()
+	if first.Index != 0 || second.Index != 1 || third.Index != 2 {
+		t.Fatalf("a fresh allocator handed out %d, %d, %d, expected the lowest slots first", first.Index, second.Index, third.Index)
+	}
+
+	allocator.release(first.Index)
+	allocator.release(second.Index)
+	reused, _ := allocator.alloc()
+	if reused.Index != second.Index {
+		t.Fatalf("reused slot %d, expected the last freed slot %d", reused.Index, second.Index)
+	}
+	if reused.Generation != second.Generation+1 {
+		t.Fatalf("a reused slot is at generation %d, expected %d", reused.Generation, second.Generation+1)
+	}
+}
+
+func TestAllocatorStats(t *testing.T) {
+	var allocator slotAllocator = newSlotAllocator(4)
+	var handles []EntityHandle = nil
+	for i := 0; i < 3; i++ {
+		handle, _ := allocator.alloc()
+		handles = append(handles, handle)
+	}
+	allocator.release(handles[0].Index)
+	allocator.release(handles[1].Index)
+	allocator.alloc()
+
+	var stats AllocatorStats = allocator.stats
+	if stats.Live != 2 || stats.HighWaterMark != 3 || stats.Capacity != 4 {
+		t.Fatalf("stats %+v, expected 2 live, a high water mark of 3 and room for 4", stats)
+	}
+
+	allocator.alloc()
+	allocator.alloc()
+	if _, ok := allocator.alloc(); ok {
+		t.Fatal("a full allocator handed out a slot")
+	}
+}
+
+// fillWorld spawns goblins until the world refuses one.
+func fillWorld(t *testing.T, world *World) {
+	for i := 0; i <= MAX_ENTITY_COUNT; i++ {
+		if _, err := world.spawnArchetype("goblin", Vector2{X: float32(i)}); err != nil {
+			return
+		}
+	}
+	t.Fatal("the world never filled up")
+}
+
+func TestWorldFullRefusesSpawns(t *testing.T) {
+	var world *World = NewWorld(DefaultConfig())
+	fillWorld(t, world)
+
+	if _, err := world.createEntity(); err != ErrWorldFull {
+		t.Fatalf("spawning into a full world returned %v, expected ErrWorldFull", err)
+	}
+	var stats AllocatorStats = world.EntityStats()
+	if stats.Live != MAX_ENTITY_COUNT || stats.Refused != 2 {
+		t.Fatalf("stats %+v, expected a full world that refused twice", stats)
+	}
+}
+
+func TestWorldFullRecyclesTheOldestAttack(t *testing.T) {
+	var config Config = DefaultConfig()
+	config.OverflowPolicy = OVERFLOW_RECYCLE_OLDEST_ATTACK
+	var world *World = NewWorld(config)
+
+	var attacks []EntityHandle = nil
+	for i := 0; i < 2; i++ {
+		attack, err := world.spawnArchetype("attack_basic", Vector2{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		attacks = append(attacks, attack.Handle)
+	}
+	for world.EntityStats().Live < MAX_ENTITY_COUNT {
+		if _, err := world.spawnArchetype("goblin", Vector2{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := world.createEntity(); err != nil {
+		t.Fatalf("a full world with attacks in it refused a spawn: %v", err)
+	}
+	if _, ok := world.Get(attacks[0]); ok {
+		t.Fatal("the oldest attack survived a full world under the recycle policy")
+	}
+	if _, ok := world.Get(attacks[1]); !ok {
+		t.Fatal("a newer attack was recycled before the oldest one")
+	}
+
+	// once the attacks are gone there is nothing left to recycle
+	fillWorld(t, world)
+	if stats := world.EntityStats(); stats.Recycled != 2 || stats.Refused != 1 {
+		t.Fatalf("stats %+v, expected both attacks recycled and then a refusal", stats)
+	}
+}
+
+func TestWorldFullNeverRecyclesTheCollidingAttack(t *testing.T) {
+	var config Config = DefaultConfig()
+	config.OverflowPolicy = OVERFLOW_RECYCLE_OLDEST_ATTACK
+	var world *World = NewWorld(config)
+
+	var attacks []EntityHandle = nil
+	for i := 0; i < 2; i++ {
+		attack, err := world.spawnArchetype("attack_basic", Vector2{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		attacks = append(attacks, attack.Handle)
+	}
+	for world.EntityStats().Live < MAX_ENTITY_COUNT {
+		if _, err := world.spawnArchetype("goblin", Vector2{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the oldest attack is the one :collision is running the hits of
+	world.collidingAttack = attacks[0]
+	if _, err := world.createEntity(); err != nil {
+		t.Fatalf("a full world with a second attack in it refused a spawn: %v", err)
+	}
+	if _, ok := world.Get(attacks[0]); !ok {
+		t.Fatal("the colliding attack was recycled from under :collision")
+	}
+	if _, ok := world.Get(attacks[1]); ok {
+		t.Fatal("the attack that wasn't colliding survived instead")
+	}
+}
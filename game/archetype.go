@@ -0,0 +1,235 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"duelingMonsters/data"
+)
+
+// Archetype is the template an entity is spawned from, one entry of
+// data/archetypes.json. Type and Sprite are names in the file and are
+// resolved while decoding.
+type Archetype struct {
+	Type       string  `json:"type"`
+	Faction    Faction `json:"faction"`
+	Sprite     string  `json:"sprite"`
+	Health     int32   `json:"health"`
+	Damage     int32   `json:"damage"`
+	Speed      int32   `json:"speed"`
+	Range      int32   `json:"range"`
+	Width      int32   `json:"width"`
+	MaxAngle   float32 `json:"max_angle"`
+	Melee      bool    `json:"melee"`
+	Projectile bool    `json:"projectile"`
+	Mana       int32   `json:"mana"`
+	ManaRegen  float32 `json:"mana_regen"`
+
+	// attacks with a width blast it, see :impact
+	DetonateOnImpact   bool    `json:"detonate_on_impact"`
+	DetonateAtMaxRange bool    `json:"detonate_at_max_range"`
+	Falloff            float32 `json:"falloff"`
+
+	Behavior ProjectileBehavior `json:"behavior"`
+
+	AI AIConfig `json:"ai"`
+
+	DamageStats
+
+	ArchType EntityArchType `json:"-"`
+	SpriteId SpriteId       `json:"-"`
+}
+
+func (archetype *Archetype) UnmarshalJSON(text []byte) error {
+	type plainArchetype Archetype
+	var plain plainArchetype
+	var decoder *json.Decoder = json.NewDecoder(bytes.NewReader(text))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&plain); err != nil {
+		return err
+	}
+	*archetype = Archetype(plain)
+
+	archType, ok := archTypeNames[archetype.Type]
+	if !ok {
+		return fmt.Errorf("unknown type %q", archetype.Type)
+	}
+	archetype.ArchType = archType
+
+	spriteId, err := spriteByNameOrPath(archetype.Sprite)
+	if err != nil {
+		return err
+	}
+	archetype.SpriteId = spriteId
+	return nil
+}
+
+func (archetype *Archetype) validate() error {
+	if archetype.Health < 0 || archetype.Damage < 0 || archetype.Speed < 0 || archetype.Range < 0 || archetype.Width < 0 {
+		return errors.New("health, damage, speed, range and width can't be negative")
+	}
+	if archetype.Mana < 0 || archetype.ManaRegen < 0 {
+		return errors.New("mana and mana_regen can't be negative")
+	}
+	if archetype.ArchType != ARCH_CARD && archetype.Health == 0 {
+		return errors.New("health must be above 0 or the entity dies on its first tick")
+	}
+	if archetype.ArchType == ARCH_ATTACK && archetype.Melee == archetype.Projectile {
+		return errors.New("an attack is either melee or projectile")
+	}
+	if archetype.Falloff < 0 || archetype.Falloff > 1 {
+		return errors.New("falloff goes from 0, full damage everywhere, to 1, none at the edge")
+	}
+	if (archetype.DetonateOnImpact || archetype.DetonateAtMaxRange) && archetype.Width <= 0 {
+		return errors.New("a detonating attack needs a width to blast")
+	}
+	if err := archetype.Behavior.validate(); err != nil {
+		return err
+	}
+	if !archetype.Projectile && archetype.Behavior != (ProjectileBehavior{}) {
+		return errors.New("only a projectile has a behavior")
+	}
+	var enemy bool = archetype.ArchType == ARCH_TROLL || archetype.ArchType == ARCH_GOBLIN || archetype.ArchType == ARCH_ENEMY
+	if enemy && archetype.Faction == FACTION_NONE {
+		return errors.New("an enemy needs a faction, with none it is neutral to everyone and can't be hurt")
+	}
+	if err := archetype.AI.validate(); err != nil {
+		return err
+	}
+	if err := archetype.DamageStats.validate(); err != nil {
+		return err
+	}
+	if archetype.Melee && archetype.MaxAngle <= 0 {
+		return errors.New("a melee attack needs a max_angle to swing through")
+	}
+	return nil
+}
+
+// ParseArchetypes reads an archetype file, a JSON object of name to
+// definition. file is only used in error messages.
+func ParseArchetypes(file string, text []byte) (map[string]Archetype, error) {
+	var archetypes map[string]Archetype = make(map[string]Archetype)
+
+	err := decodeDataEntries(file, text, '{', "archetype", func(name string, archetype *Archetype) error {
+		if _, ok := archetypes[name]; ok {
+			return errors.New("defined twice")
+		}
+		if err := archetype.validate(); err != nil {
+			return err
+		}
+		archetypes[name] = *archetype
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, required := range []string{"player", "troll", "goblin", "card_fireball", "attack_fireball"} {
+		if _, ok := archetypes[required]; !ok {
+			return nil, &DataError{File: file, Line: 1, Message: fmt.Sprintf("missing archetype %q, the game spawns it by name", required)}
+		}
+	}
+	var names []string = nil
+	for name := range archetypes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		var attack string = archetypes[name].AI.Attack
+		if attack != "" && archetypes[attack].ArchType != ARCH_ATTACK {
+			return nil, &DataError{File: file, Line: 1, Message: fmt.Sprintf("archetype %q: ai attack %q is not an attack archetype", name, attack)}
+		}
+		var behavior ProjectileBehavior = archetypes[name].Behavior
+		if err := behavior.validateSplit(archetypes); err != nil {
+			return nil, &DataError{File: file, Line: 1, Message: fmt.Sprintf("archetype %q: %v", name, err)}
+		}
+	}
+	return archetypes, nil
+}
+
+func LoadArchetypes(path string) (map[string]Archetype, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseArchetypes(path, text)
+}
+
+// :globals default archetypes
+// parsed once from the embedded data file, in init so the name tables the
+// decoder uses are set up first
+var defaultArchetypes map[string]Archetype
+
+func init() {
+	defaultArchetypes = mustParseEmbedded(data.ArchetypesFile)
+}
+
+func mustParseEmbedded(file string) map[string]Archetype {
+	text, err := data.Files.ReadFile(file)
+	assert(err == nil, "embedded "+file+" missing")
+	archetypes, err := ParseArchetypes(filepath.Join("data", file), text)
+	if err != nil {
+		panic(err)
+	}
+	return archetypes
+}
+
+// :spawn archetypes
+
+func updateCollisionRectangle(en *Entity) {
+	var sprite *SpriteSize = getSpriteSize(en.SpriteId)
+	en.CollisionRectangle.X = (en.Position.X - float32(sprite.Width)/2)
+	en.CollisionRectangle.Y = en.Position.Y - float32(sprite.Height/2)
+	en.CollisionRectangle.Width = float32(sprite.Width)
+	en.CollisionRectangle.Height = float32(sprite.Height)
+}
+
+func applyArchetype(en *Entity, name string, archetype *Archetype) {
+	en.Archetype = name
+	en.Type = archetype.ArchType
+	en.SpriteId = archetype.SpriteId
+	en.Health = archetype.Health
+	en.Faction = archetype.Faction
+	en.MaxHealth = archetype.Health
+	en.Damage = archetype.Damage
+	en.Speed = archetype.Speed
+	en.Range = archetype.Range
+	en.Width = archetype.Width
+	en.MaxAngle = archetype.MaxAngle
+	en.isMelee = archetype.Melee
+	en.isProjectile = archetype.Projectile
+	en.detonateOnImpact = archetype.DetonateOnImpact
+	en.detonateAtMaxRange = archetype.DetonateAtMaxRange
+	en.Falloff = archetype.Falloff
+	en.Behavior = archetype.Behavior
+	en.ProjectileSpeed = float32(archetype.Speed)
+	en.AI = archetype.AI
+	en.DamageStats = archetype.DamageStats
+	en.MaxMana = archetype.Mana
+	en.ManaRegen = archetype.ManaRegen
+	en.Mana = float32(archetype.Mana)
+}
+
+// spawnArchetype is the one way entities enter the world.
+func (world *World) spawnArchetype(name string, position Vector2) (*Entity, error) {
+	archetype, ok := world.Config.Archetypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown archetype %q", name)
+	}
+
+	en, err := world.createEntity()
+	if err != nil {
+		return nil, err
+	}
+
+	applyArchetype(en, name, &archetype)
+	en.Position = position
+	en.PreviousPosition = position
+	updateCollisionRectangle(en)
+	return en, nil
+}
@@ -0,0 +1,35 @@
+package game
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestArchetypeErrorsPointAtTheLine(t *testing.T) {
+	var tests = []struct {
+		name    string
+		text    string
+		line    int
+		message string
+	}{
+		{"syntax", "{\n  \"goblin\": {\n    \"health\": 5,,\n  }\n}", 3, "invalid character"},
+		{"unknown field", "{\n  \"goblin\": {\n    \"type\": \"goblin\",\n    \"helth\": 5\n  }\n}", 4, "unknown field"},
+		{"wrong type", "{\n  \"goblin\": {\n    \"type\": \"goblin\",\n    \"health\": \"5\"\n  }\n}", 4, "cannot unmarshal"},
+		{"unknown type", "{\n  \"goblin\": {\n    \"type\": \"gnome\"\n  }\n}", 2, "unknown type"},
+		{"invalid", "{\n\n  \"goblin\": {\n    \"type\": \"goblin\", \"sprite\": \"goblin\", \"health\": 0\n  }\n}", 3, "health must be above 0"},
+		{"no faction", "{\n  \"goblin\": {\"type\": \"goblin\", \"sprite\": \"goblin\", \"health\": 5}\n}", 2, "needs a faction"},
+		{"twice", "{\n  \"goblin\": {\"type\": \"goblin\", \"sprite\": \"goblin\", \"health\": 5, \"faction\": \"monster\"},\n  \"goblin\": {\"type\": \"goblin\", \"sprite\": \"goblin\", \"health\": 5, \"faction\": \"monster\"}\n}", 3, "defined twice"},
+		{"truncated", "{\n  \"goblin\": {\n", 3, "unexpected end of file"},
+	}
+	for _, test := range tests {
+		_, err := ParseArchetypes("test.json", []byte(test.text))
+		var dataErr *DataError
+		if !errors.As(err, &dataErr) {
+			t.Fatalf("%s: expected a DataError, got %v", test.name, err)
+		}
+		if dataErr.File != "test.json" || dataErr.Line != test.line || !strings.Contains(dataErr.Message, test.message) {
+			t.Errorf("%s: got %v, expected test.json:%d: ...%s...", test.name, err, test.line, test.message)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package game
+
+import "math"
+
+// :attack spawning
+// Cards and enemies spawn their attacks the same way: from the owner toward
+// a target, a projectile flying out to its range and a melee attack swinging
+// around the owner.
+
+// a swing, or a projectile passing through, hits each target once, past this
+// many targets the rest are missed
+const MAX_HIT_TARGETS int = 8
+
+func (world *World) spawnAttack(name string, owner *Entity, target Vector2) (*Entity, error) {
+	attack, err := world.spawnArchetype(name, owner.Position)
+	if err != nil {
+		return nil, err
+	}
+	attack.Owner = owner.Handle
+	attack.Faction = owner.Faction
+	attack.Power += owner.Power
+	attack.CritChance = max(attack.CritChance, owner.CritChance)
+	attack.CritMultiplier = max(attack.CritMultiplier, owner.CritMultiplier)
+	attack.Lifesteal = min(attack.Lifesteal+owner.Lifesteal, 1)
+	attack.inputAxis = Vector2Normalize(Vector2Subtract(target, owner.Position))
+	attack.MaxPosition = Vector2Add(owner.Position, Vector2Scale(attack.inputAxis, float32(attack.Range)))
+	if attack.isMelee {
+		// the tip of the blade reaches Range
+		attack.Radius = max(float32(attack.Range)-float32(getSpriteSize(attack.SpriteId).Height)/2, 0)
+		placeSwing(attack, owner.Position)
+		attack.PreviousPosition = attack.Position
+		updateCollisionRectangle(attack)
+	}
+	return attack, nil
+}
+
+// :swing
+// A melee attack sweeps MaxAngle degrees centered on the direction it was
+// aimed in, Angle is how far along it is. It stays Radius away from
+// CenterPosition, the owner's position as of this tick.
+
+// SwingHeading is the direction the blade of a melee attack points in
+// degrees, as raylib rotates.
+func (en *Entity) SwingHeading() float32 {
+	var aim float32 = float32(math.Atan2(float64(en.inputAxis.Y), float64(en.inputAxis.X)) * 180 / math.Pi)
+	return aim - en.MaxAngle/2 + en.Angle
+}
+
+func (en *Entity) IsSwinging() bool {
+	return en.Type == ARCH_ATTACK && en.isMelee
+}
+
+func placeSwing(attack *Entity, center Vector2) {
+	var heading float64 = float64(attack.SwingHeading()) * math.Pi / 180
+	attack.CenterPosition = center
+	attack.Position = Vector2Add(center, Vector2{X: float32(math.Cos(heading)) * attack.Radius, Y: float32(math.Sin(heading)) * attack.Radius})
+}
+
+// updateSwing advances a swing by Speed degrees per second around its owner,
+// a swing whose owner is gone is dropped.
+func (world *World) updateSwing(attack *Entity, delta_t float32) {
+	owner, ok := world.Get(attack.Owner)
+	if !ok {
+		world.destroyEntity(attack)
+		return
+	}
+	attack.Angle = min(attack.Angle+float32(attack.Speed)*delta_t, attack.MaxAngle)
+	placeSwing(attack, owner.Position)
+}
+
+func hasHit(attack *Entity, target EntityHandle) bool {
+	for i := 0; i < MAX_HIT_TARGETS; i++ {
+		if attack.HitTargets[i] == target {
+			return true
+		}
+	}
+	return false
+}
+
+// recordHit is false when the attack already hit target.
+func recordHit(attack *Entity, target EntityHandle) bool {
+	for i := 0; i < MAX_HIT_TARGETS; i++ {
+		if attack.HitTargets[i] == target {
+			return false
+		}
+		if attack.HitTargets[i] == (EntityHandle{}) {
+			attack.HitTargets[i] = target
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package game
+
+import "testing"
+
+func TestSwingHitsEachTargetOnce(t *testing.T) {
+	world, playerEntity := testWorld()
+	var goblins []*Entity = []*Entity{
+		dummyAt(t, world, Vector2{X: 16, Y: -10}),
+		dummyAt(t, world, Vector2{X: 16, Y: 10}),
+	}
+	// behind the player, out of the arc
+	var behind *Entity = dummyAt(t, world, Vector2{X: -16})
+
+	attack, err := world.spawnAttack("attack_sword", playerEntity, Vector2{X: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var handle EntityHandle = attack.Handle
+	var damage int32 = attack.Damage
+	for i := 0; i < 60; i++ {
+		world.Step(world.TickDuration(), InputState{})
+	}
+
+	for i, goblin := range goblins {
+		if taken := 1000 - goblin.Health; taken != damage {
+			t.Fatalf("goblin %d in the arc took %d damage, one hit of the sword does %d", i, taken, damage)
+		}
+	}
+	if behind.Health != 1000 {
+		t.Fatal("the swing hit a goblin behind the player")
+	}
+	if _, ok := world.Get(handle); ok {
+		t.Fatal("the swing outlived its arc")
+	}
+}
+
+func TestSwingFollowsItsOwner(t *testing.T) {
+	world, playerEntity := testWorld()
+	attack, err := world.spawnAttack("attack_sword", playerEntity, Vector2{X: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	world.Step(world.TickDuration(), InputState{Axis: Vector2{Y: 1}})
+	if attack.CenterPosition != playerEntity.Position {
+		t.Fatalf("the swing turns around %v, its owner moved to %v", attack.CenterPosition, playerEntity.Position)
+	}
+	if distance := Vector2Distance(attack.Position, playerEntity.Position); !almostEquals(distance, attack.Radius, 1e-3) {
+		t.Fatalf("the blade is %v from its owner, expected %v", distance, attack.Radius)
+	}
+}
@@ -0,0 +1,125 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"duelingMonsters/data"
+)
+
+// Card is one entry of data/cards.json. Archetype is what the card looks like
+// while it is in the hand, Effects what playing it does, in order.
+type Card struct {
+	Archetype string   `json:"archetype"`
+	Effects   []Effect `json:"effects"`
+	// mana the caster spends to play it
+	Cost int32 `json:"cost"`
+	// how many of the card the starting deck holds
+	Copies int32 `json:"copies"`
+}
+
+func (card *Card) validate(archetypes map[string]Archetype, statuses map[string]Status) error {
+	if archetype, ok := archetypes[card.Archetype]; !ok {
+		return fmt.Errorf("unknown archetype %q", card.Archetype)
+	} else if archetype.ArchType != ARCH_CARD {
+		return fmt.Errorf("archetype %q is not a card", card.Archetype)
+	}
+	if len(card.Effects) == 0 {
+		return errors.New("playing it does nothing, add effects")
+	}
+	for i := 0; i < len(card.Effects); i++ {
+		if err := card.Effects[i].validate(archetypes, statuses); err != nil {
+			return fmt.Errorf("effect %d (%s): %w", i+1, card.Effects[i].Type, err)
+		}
+	}
+	if card.Copies < 0 || card.Cost < 0 {
+		return errors.New("copies and cost can't be negative")
+	}
+	return nil
+}
+
+// ParseCards reads a card file, a JSON object of name to definition. Every
+// card names archetypes from archetypes. file is only used in error messages.
+func ParseCards(file string, text []byte, archetypes map[string]Archetype, statuses map[string]Status) (map[string]Card, error) {
+	var cards map[string]Card = make(map[string]Card)
+	var copies int32 = 0
+
+	err := decodeDataEntries(file, text, '{', "card", func(name string, card *Card) error {
+		if _, ok := cards[name]; ok {
+			return errors.New("defined twice")
+		}
+		if err := card.validate(archetypes, statuses); err != nil {
+			return err
+		}
+		cards[name] = *card
+		copies += card.Copies
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if copies == 0 {
+		return nil, &DataError{File: file, Line: 1, Message: "the starting deck is empty, give a card some copies"}
+	}
+	return cards, nil
+}
+
+func LoadCards(path string, archetypes map[string]Archetype, statuses map[string]Status) (map[string]Card, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCards(path, text, archetypes, statuses)
+}
+
+// :globals default cards
+var defaultCards map[string]Card
+
+func init() {
+	text, err := data.Files.ReadFile(data.CardsFile)
+	assert(err == nil, "embedded "+data.CardsFile+" missing")
+	defaultCards, err = ParseCards(filepath.Join("data", data.CardsFile), text, defaultArchetypes, defaultStatuses)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// CanAfford reports whether caster has the mana to play card.
+func (world *World) CanAfford(card *Entity, caster *Entity) bool {
+	definition, ok := world.Config.Cards[card.Card]
+	return ok && caster.Mana >= float32(definition.Cost)
+}
+
+// playCard casts a card of the hand from caster toward target: it pays the
+// cost, goes to the discard pile and its effects run. A card the caster can't
+// afford stays in the hand.
+func (world *World) playCard(card *Entity, caster *Entity, target Vector2) bool {
+	definition, ok := world.Config.Cards[card.Card]
+	if !ok {
+		return false
+	}
+	if status, blocked := world.castingBlockedBy(caster); blocked {
+		world.emit(Event{Type: EVENT_CAST_REFUSED, Entity: caster.Handle, Card: card.Card, Status: status})
+		return false
+	}
+	if !world.CanAfford(card, caster) {
+		world.emit(Event{Type: EVENT_CAST_REFUSED, Entity: caster.Handle, Card: card.Card})
+		return false
+	}
+
+	caster.Mana -= float32(definition.Cost)
+	world.emit(Event{Type: EVENT_CARD_PLAYED, Entity: caster.Handle, Card: card.Card})
+	if caster.Handle == world.Player {
+		world.Stats.CardsPlayed += 1
+	}
+	// the same target the aim preview showed
+	target = aimTarget(card, caster, target)
+	// discarded first so a draw effect sees the room the card leaves
+	var played Entity = *card
+	world.discardCard(card)
+	world.runEffects(definition.Effects, caster, target, &played)
+	return true
+}
@@ -0,0 +1,59 @@
+package game
+
+import "testing"
+
+// firstCard is the card in the lowest hand slot.
+func firstCard(t *testing.T, world *World) *Entity {
+	for i := 0; i < MAX_HAND_COUNT; i++ {
+		if world.Hand.Cards[i].IsValid {
+			return &world.Hand.Cards[i]
+		}
+	}
+	t.Fatal("the hand is empty")
+	return nil
+}
+
+func TestCardRefusedWithoutTheMana(t *testing.T) {
+	world, playerEntity := testWorld()
+	var card *Entity = firstCard(t, world)
+	var handle EntityHandle = card.Handle
+	var cost float32 = float32(world.Config.Cards[card.Card].Cost)
+	playerEntity.Mana = cost - 0.5
+
+	if world.playCard(card, playerEntity, Vector2{X: 100}) {
+		t.Fatal("played a card the player can't afford")
+	}
+	if _, ok := world.Hand.Get(handle); !ok {
+		t.Fatal("a refused card left the hand")
+	}
+	if playerEntity.Mana != cost-0.5 {
+		t.Fatalf("a refused card took mana, %v left", playerEntity.Mana)
+	}
+	if len(world.Events) != 1 || world.Events[0].Type != EVENT_CAST_REFUSED {
+		t.Fatalf("expected one refused cast event, got %v", world.Events)
+	}
+
+	playerEntity.Mana = cost
+	if !world.playCard(card, playerEntity, Vector2{X: 100}) {
+		t.Fatal("couldn't play a card the player can just afford")
+	}
+	if playerEntity.Mana != 0 {
+		t.Fatalf("playing a card of cost %v left %v of %v mana", cost, playerEntity.Mana, cost)
+	}
+}
+
+func TestManaRegeneratesUpToTheMax(t *testing.T) {
+	world, playerEntity := testWorld()
+	playerEntity.Mana = 0
+	world.Step(world.TickDuration(), InputState{})
+	if expected := playerEntity.ManaRegen * world.TickDuration(); !almostEquals(playerEntity.Mana, expected, 1e-5) {
+		t.Fatalf("a tick regenerated %v mana, expected %v", playerEntity.Mana, expected)
+	}
+
+	for i := 0; i < int(world.Config.TickRate)*60; i++ {
+		world.Step(world.TickDuration(), InputState{})
+	}
+	if playerEntity.Mana != float32(playerEntity.MaxMana) {
+		t.Fatalf("a minute of regeneration left %v of %d mana", playerEntity.Mana, playerEntity.MaxMana)
+	}
+}
@@ -0,0 +1,262 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// :damage
+// Every hit goes through applyDamage: the attacker's Power and a roll for a
+// critical hit scale it up, the target's resistance to its type scales it
+// down, Armor takes a flat amount off physical hits and shields absorb what
+// they can. What comes out is a DamageResult, emitted as EVENT_DAMAGE for the
+// renderer and passed to onDamage for what the simulation does with it.
+
+// :enum DamageType
+type DamageType int
+
+const (
+	DAMAGE_PHYSICAL   DamageType = 0
+	DAMAGE_FIRE       DamageType = 1
+	DAMAGE_ICE        DamageType = 2
+	DAMAGE_POISON     DamageType = 3
+	DAMAGE_TYPE_COUNT int        = 4
+)
+
+var damageTypeNames = [DAMAGE_TYPE_COUNT]string{
+	DAMAGE_PHYSICAL: "physical",
+	DAMAGE_FIRE:     "fire",
+	DAMAGE_ICE:      "ice",
+	DAMAGE_POISON:   "poison",
+}
+
+func damageTypeByName(name string) (DamageType, error) {
+	for i := 0; i < DAMAGE_TYPE_COUNT; i++ {
+		if damageTypeNames[i] == name {
+			return DamageType(i), nil
+		}
+	}
+	return DAMAGE_PHYSICAL, fmt.Errorf("unknown damage type %q", name)
+}
+
+func (damageType DamageType) String() string {
+	if damageType >= 0 && int(damageType) < DAMAGE_TYPE_COUNT {
+		return damageTypeNames[damageType]
+	}
+	return fmt.Sprintf("DamageType(%d)", int(damageType))
+}
+
+func (damageType DamageType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(damageType.String())
+}
+
+func (damageType *DamageType) UnmarshalJSON(text []byte) error {
+	var name string
+	if err := json.Unmarshal(text, &name); err != nil {
+		return err
+	}
+	found, err := damageTypeByName(name)
+	if err != nil {
+		return err
+	}
+	*damageType = found
+	return nil
+}
+
+// Resistances is the part of each type of damage an entity shrugs off: 0
+// takes it all, 1 is immune and below 0 takes more. In the data files it is an
+// object of damage type name to resistance, types left out are 0.
+type Resistances [DAMAGE_TYPE_COUNT]float32
+
+func (resistances Resistances) MarshalJSON() ([]byte, error) {
+	var named map[string]float32 = make(map[string]float32)
+	for i := 0; i < DAMAGE_TYPE_COUNT; i++ {
+		if resistances[i] != 0 {
+			named[damageTypeNames[i]] = resistances[i]
+		}
+	}
+	return json.Marshal(named)
+}
+
+func (resistances *Resistances) UnmarshalJSON(text []byte) error {
+	var named map[string]float32
+	if err := json.Unmarshal(text, &named); err != nil {
+		return err
+	}
+	*resistances = Resistances{}
+	for name, resistance := range named {
+		damageType, err := damageTypeByName(name)
+		if err != nil {
+			return err
+		}
+		resistances[damageType] = resistance
+	}
+	return nil
+}
+
+// DamageStats are what an entity brings to a fight, part of both Archetype and
+// Entity. An attack deals DamageType and takes the Power, crits and Lifesteal
+// of its owner when spawned, see spawnAttack.
+type DamageStats struct {
+	DamageType DamageType `json:"damage_type"`
+	// damage dealt is scaled by 1 + Power
+	Power float32 `json:"power"`
+	// chance from 0 to 1 for a hit to deal CritMultiplier times the damage
+	CritChance     float32 `json:"crit_chance"`
+	CritMultiplier float32 `json:"crit_multiplier"`
+	// part of the damage dealt the owner heals
+	Lifesteal float32 `json:"lifesteal"`
+
+	// a hit pushes the target away at Knockback units per second, the push
+	// dying down by Config.VelocityDecay, and keeps it from moving for HitStun
+	// seconds, interrupting an enemy's wind up
+	Knockback float32 `json:"knockback"`
+	HitStun   float32 `json:"hit_stun"`
+
+	// taken off every physical hit, a hit that isn't resisted fully still
+	// deals at least 1
+	Armor       int32       `json:"armor"`
+	Resistances Resistances `json:"resistances"`
+	// seconds a hit leaves the entity untouchable for
+	Invulnerability float32 `json:"invulnerability"`
+}
+
+func (stats *DamageStats) validate() error {
+	if stats.Power < -1 {
+		return errors.New("power below -1 would heal")
+	}
+	if stats.CritChance < 0 || stats.CritChance > 1 {
+		return errors.New("crit_chance goes from 0 to 1")
+	}
+	if stats.CritChance > 0 && stats.CritMultiplier < 1 {
+		return errors.New("a crit_chance needs a crit_multiplier of at least 1")
+	}
+	if stats.Lifesteal < 0 || stats.Lifesteal > 1 {
+		return errors.New("lifesteal goes from 0 to 1")
+	}
+	if stats.Armor < 0 || stats.Knockback < 0 || stats.HitStun < 0 || stats.Invulnerability < 0 {
+		return errors.New("armor, knockback, hit_stun and invulnerability can't be negative")
+	}
+	for i := 0; i < DAMAGE_TYPE_COUNT; i++ {
+		if stats.Resistances[i] > 1 {
+			return fmt.Errorf("%s resistance above 1 would heal", damageTypeNames[i])
+		}
+	}
+	return nil
+}
+
+// DamageResult is one hit after the whole pipeline.
+type DamageResult struct {
+	Target EntityHandle
+	// the entity credited with the hit, the owner of an attack
+	Source EntityHandle
+	Type   DamageType
+	// before and after modifiers, Amount is the health the target lost and
+	// Absorbed what its shields took
+	Raw      int32
+	Amount   int32
+	Absorbed int32
+	Critical bool
+	Killed   bool
+}
+
+// applyDamage hits target with amount of damageType. attacker is the attack
+// entity or, for effects, the caster, nil for damage nobody is behind. Damage
+// over time has no attacker, it neither pushes nor stops for invulnerability.
+func (world *World) applyDamage(attacker *Entity, target *Entity, amount int32, damageType DamageType) DamageResult {
+	var result DamageResult = DamageResult{
+		Target: target.Handle,
+		Type:   damageType,
+		Raw:    amount,
+	}
+	if attacker != nil && target.InvulnerableTimer > 0 {
+		return result
+	}
+
+	// :damage attacker
+	var scaled float32 = float32(amount)
+	if attacker != nil {
+		result.Source = attacker.Handle
+		if attacker.Type == ARCH_ATTACK {
+			result.Source = attacker.Owner
+		}
+		scaled *= 1 + attacker.Power
+		if attacker.CritChance > 0 && world.Random.Float32() < attacker.CritChance {
+			scaled *= attacker.CritMultiplier
+			result.Critical = true
+		}
+	}
+
+	// :damage target
+	var resistance float32 = target.Resistances[result.Type]
+	scaled *= 1 - resistance
+	var dealt int32 = int32(math.Round(float64(scaled)))
+	if result.Type == DAMAGE_PHYSICAL {
+		dealt -= target.Armor
+	}
+	if resistance < 1 {
+		dealt = max(dealt, 1)
+	} else {
+		dealt = 0
+	}
+
+	result.Amount = absorbDamage(target, dealt)
+	result.Absorbed = dealt - result.Amount
+	dealt = result.Amount
+	var wasAlive bool = target.Health > 0
+	target.Health -= dealt
+	result.Killed = wasAlive && target.Health <= 0
+
+	world.emit(Event{Type: EVENT_DAMAGE, Entity: target.Handle, Position: target.Position, Damage: result})
+	// :damage stats
+	if result.Source == world.Player {
+		world.Stats.DamageDealt += result.Amount
+	}
+	if result.Target == world.Player {
+		world.Stats.DamageTaken += result.Amount
+	}
+	if attacker != nil {
+		world.onDamage(attacker, target, result)
+	}
+	return result
+}
+
+// onDamage is where the simulation reacts to a hit that landed.
+func (world *World) onDamage(attacker *Entity, target *Entity, result DamageResult) {
+	// :damage knockback, away from where the hit came from, a swing pushes
+	// out from its owner
+	if attacker.Knockback > 0 {
+		var from Vector2 = attacker.Position
+		if attacker.IsSwinging() {
+			from = attacker.CenterPosition
+		}
+		var away Vector2 = Vector2Normalize(Vector2Subtract(target.Position, from))
+		if away == (Vector2{}) {
+			away = attacker.inputAxis
+		}
+		target.Velocity = Vector2Add(target.Velocity, Vector2Scale(away, attacker.Knockback))
+	}
+	if attacker.HitStun > 0 {
+		target.HitStunTimer = max(target.HitStunTimer, attacker.HitStun)
+		if target.AIState == AI_WIND_UP {
+			world.setAIState(target, AI_RECOVER)
+		}
+	}
+	target.InvulnerableTimer = max(target.InvulnerableTimer, target.Invulnerability)
+	target.LastHitBy = result.Source
+
+	// :damage statuses, a shield that took the whole hit keeps them off too
+	if attacker.Inflicts != "" && result.Amount > 0 {
+		world.applyStatus(target, attacker.Inflicts, attacker.InflictDuration)
+	}
+
+	// :damage lifesteal
+	if attacker.Lifesteal > 0 && result.Amount > 0 {
+		if source, ok := world.Get(result.Source); ok && source.Health > 0 {
+			var healed int32 = int32(float32(result.Amount) * attacker.Lifesteal)
+			source.Health = min(source.Health+healed, max(source.MaxHealth, source.Health))
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package game
+
+import "testing"
+
+func TestDamagePipeline(t *testing.T) {
+	var tests = []struct {
+		name       string
+		target     string
+		amount     int32
+		damageType DamageType
+		power      float32
+		expected   int32
+	}{
+		{"plain hit", "goblin", 4, DAMAGE_PHYSICAL, 0, 4},
+		{"power scales up", "goblin", 4, DAMAGE_FIRE, 0.5, 6},
+		// the troll resists half of physical damage and has 1 armor
+		{"resisted and armored", "troll", 10, DAMAGE_PHYSICAL, 0, 4},
+		{"armor leaves at least 1", "troll", 1, DAMAGE_PHYSICAL, 0, 1},
+		// and burns easily, armor only stops physical hits
+		{"weak to fire", "troll", 10, DAMAGE_FIRE, 0, 15},
+		{"no resistance", "troll", 10, DAMAGE_ICE, 0, 10},
+	}
+	for _, test := range tests {
+		world, playerEntity := testWorld()
+		playerEntity.CritChance = 0
+		playerEntity.Power = test.power
+		var target *Entity = spawnAt(t, world, test.target, Vector2{X: 50})
+		var health int32 = target.Health
+
+		var result DamageResult = world.applyDamage(playerEntity, target, test.amount, test.damageType)
+		if result.Amount != test.expected || health-target.Health != test.expected {
+			t.Errorf("%s: dealt %d and took %d health, expected %d", test.name, result.Amount, health-target.Health, test.expected)
+		}
+	}
+}
+
+func TestDamageImmuneTakesNothing(t *testing.T) {
+	world, playerEntity := testWorld()
+	var goblin *Entity = dummyAt(t, world, Vector2{X: 50})
+	goblin.Resistances[DAMAGE_ICE] = 1
+	if result := world.applyDamage(playerEntity, goblin, 10, DAMAGE_ICE); result.Amount != 0 || goblin.Health != 1000 {
+		t.Fatalf("an immune goblin took %d damage", result.Amount)
+	}
+}
+
+func TestDamageCritsOnTheRoll(t *testing.T) {
+	// the first seeds whose first roll is and isn't under the crit chance
+	var critSeed, plainSeed uint64 = 0, 0
+	for seed := uint64(1); critSeed == 0 || plainSeed == 0; seed++ {
+		var random Random = NewRandom(seed)
+		if random.Float32() < 0.25 {
+			critSeed = seed
+		} else {
+			plainSeed = seed
+		}
+	}
+
+	for _, seed := range []uint64{critSeed, plainSeed} {
+		world, playerEntity := testWorld()
+		playerEntity.CritChance = 0.25
+		playerEntity.CritMultiplier = 3
+		var goblin *Entity = dummyAt(t, world, Vector2{X: 50})
+		world.Random = NewRandom(seed)
+
+		var result DamageResult = world.applyDamage(playerEntity, goblin, 4, DAMAGE_PHYSICAL)
+		if seed == critSeed && (!result.Critical || result.Amount != 12) {
+			t.Fatalf("seed %d rolls a crit, the hit dealt %d critical %v", seed, result.Amount, result.Critical)
+		}
+		if seed == plainSeed && (result.Critical || result.Amount != 4) {
+			t.Fatalf("seed %d doesn't roll a crit, the hit dealt %d critical %v", seed, result.Amount, result.Critical)
+		}
+	}
+}
+
+func TestLifestealHealsTheOwnerUpToItsMax(t *testing.T) {
+	world, playerEntity := testWorld()
+	var goblin *Entity = dummyAt(t, world, Vector2{X: 50})
+	attack, err := world.spawnAttack("attack_basic", playerEntity, goblin.Position)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attack.CritChance = 0
+	attack.Lifesteal = 0.5
+
+	playerEntity.Health = playerEntity.MaxHealth - 10
+	world.applyDamage(attack, goblin, 8, DAMAGE_PHYSICAL)
+	if playerEntity.Health != playerEntity.MaxHealth-6 {
+		t.Fatalf("half of 8 damage healed the player to %d of %d", playerEntity.Health, playerEntity.MaxHealth)
+	}
+
+	world.applyDamage(attack, goblin, 40, DAMAGE_PHYSICAL)
+	if playerEntity.Health != playerEntity.MaxHealth {
+		t.Fatalf("lifesteal took the player to %d, its max is %d", playerEntity.Health, playerEntity.MaxHealth)
+	}
+}
+
+func TestKnockbackPushesAwayAndDiesDown(t *testing.T) {
+	world, playerEntity := testWorld()
+	var goblin *Entity = dummyAt(t, world, Vector2{X: 50})
+	attack, err := world.spawnAttack("attack_basic", playerEntity, goblin.Position)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attack.CritChance = 0
+	world.applyDamage(attack, goblin, 1, DAMAGE_PHYSICAL)
+	world.destroyEntity(attack)
+	if goblin.Velocity.X <= 0 || goblin.Velocity.Y != 0 {
+		t.Fatalf("a hit from the left pushed the goblin at %v", goblin.Velocity)
+	}
+
+	var previous Vector2 = goblin.Velocity
+	for i := 0; i < 60; i++ {
+		world.Step(world.TickDuration(), InputState{})
+		if Vector2Length(goblin.Velocity) > Vector2Length(previous) {
+			t.Fatalf("the push grew from %v to %v", previous, goblin.Velocity)
+		}
+		previous = goblin.Velocity
+	}
+	if goblin.Position.X <= 50 {
+		t.Fatalf("the pushed goblin is at %v, expected it further right", goblin.Position)
+	}
+	if goblin.Velocity != (Vector2{}) {
+		t.Fatalf("a second after the hit the goblin still moves at %v", goblin.Velocity)
+	}
+}
+
+func TestInvulnerabilityIgnoresHits(t *testing.T) {
+	world, playerEntity := testWorld()
+	var goblin *Entity = dummyAt(t, world, Vector2{X: 50})
+	goblin.CritChance = 0
+	var health int32 = playerEntity.Health
+
+	world.applyDamage(goblin, playerEntity, 5, DAMAGE_PHYSICAL)
+	world.Events = world.Events[:0]
+	world.applyDamage(goblin, playerEntity, 5, DAMAGE_PHYSICAL)
+	if playerEntity.Health != health-5 || len(world.Events) != 0 {
+		t.Fatalf("the second hit during invulnerability landed, health %d of %d", playerEntity.Health, health)
+	}
+
+	for i := 0; i < int(playerEntity.Invulnerability*world.Config.TickRate)+1; i++ {
+		world.Step(world.TickDuration(), InputState{})
+	}
+	world.applyDamage(goblin, playerEntity, 5, DAMAGE_PHYSICAL)
+	if playerEntity.Health != health-10 {
+		t.Fatal("a hit after the invulnerability ran out was ignored")
+	}
+}
+
+func TestHitStunHoldsTheEnemy(t *testing.T) {
+	world, _ := testWorld()
+	var goblin *Entity = spawnAt(t, world, "goblin", Vector2{X: 20})
+	var attacks map[EntityHandle]bool = make(map[EntityHandle]bool)
+	step := func(ticks int) {
+		for i := 0; i < ticks; i++ {
+			stepEvents(world, 1, InputState{}, func(event Event) {})
+			for j := 0; j < MAX_ENTITY_COUNT; j++ {
+				var entity *Entity = &world.Entities[j]
+				if entity.IsValid && entity.Type == ARCH_ATTACK && entity.Owner == goblin.Handle {
+					attacks[entity.Handle] = true
+				}
+			}
+		}
+	}
+	stepUntil := func(state AIState) {
+		for i := 0; i < 240 && goblin.AIState != state; i++ {
+			step(1)
+		}
+		if goblin.AIState != state {
+			t.Fatalf("the goblin never got to %v", state)
+		}
+	}
+
+	// stunned in the wind up, it neither attacks nor winds up any further
+	stepUntil(AI_WIND_UP)
+	goblin.HitStunTimer = 0.5
+	var timer float32 = goblin.AITimer
+	step(20)
+	if goblin.AIState != AI_WIND_UP || goblin.AITimer != timer || len(attacks) != 0 {
+		t.Fatalf("the stunned goblin went on to %v after %v and attacked %d times", goblin.AIState, goblin.AITimer, len(attacks))
+	}
+
+	// stunned on the tick it attacked, the attack isn't repeated when the stun
+	// is over
+	stepUntil(AI_ATTACK)
+	goblin.HitStunTimer = 0.5
+	step(60)
+	if len(attacks) != 1 {
+		t.Fatalf("the goblin attacked %d times, expected once", len(attacks))
+	}
+}
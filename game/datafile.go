@@ -0,0 +1,109 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DataError points at the place in a data file a definition went wrong.
+type DataError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (err *DataError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", err.File, err.Line, err.Message)
+}
+
+func lineAt(text []byte, offset int64) int {
+	if offset > int64(len(text)) {
+		offset = int64(len(text))
+	}
+	return bytes.Count(text[:offset], []byte("\n")) + 1
+}
+
+// decodeDataEntries decodes a data file holding either a JSON object of name
+// to entry (open is '{') or a list of entries (open is '['), one entry at a
+// time so every error can name the entry and point at its line. Unknown fields
+// are errors, a typo in a field name must not silently fall back to zero.
+// each is called for every entry in file order, its error is reported at the
+// line the entry starts.
+func decodeDataEntries[T any](file string, text []byte, open json.Delim, kind string, each func(name string, entry *T) error) error {
+	var decoder *json.Decoder = json.NewDecoder(bytes.NewReader(text))
+
+	dataError := func(offset int64, format string, args ...any) *DataError {
+		return &DataError{File: file, Line: lineAt(text, offset), Message: fmt.Sprintf(format, args...)}
+	}
+	syntaxError := func(err error) *DataError {
+		var jsonSyntaxError *json.SyntaxError
+		if errors.As(err, &jsonSyntaxError) {
+			return dataError(jsonSyntaxError.Offset, "%v", err)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return dataError(int64(len(text)), "unexpected end of file")
+		}
+		return dataError(decoder.InputOffset(), "%v", err)
+	}
+	// errors decoding a single entry have offsets relative to where the entry
+	// starts in the file, unknown fields have none so the field is searched
+	entryError := func(err error, label string, raw []byte, valueOffset int64) *DataError {
+		var typeError *json.UnmarshalTypeError
+		if errors.As(err, &typeError) {
+			return dataError(valueOffset+typeError.Offset, "%s: %v", label, err)
+		}
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			if index := bytes.Index(raw, []byte(field)); index >= 0 {
+				return dataError(valueOffset+int64(index), "%s: %v", label, err)
+			}
+		}
+		return dataError(valueOffset, "%s: %v", label, err)
+	}
+
+	if token, err := decoder.Token(); err != nil {
+		return syntaxError(err)
+	} else if token != open {
+		if open == '{' {
+			return dataError(0, "expected an object of %s name to definition", kind)
+		}
+		return dataError(0, "expected a list of %s definitions", kind)
+	}
+
+	for index := 0; decoder.More(); index++ {
+		var name string = fmt.Sprint(index + 1)
+		var label string = fmt.Sprintf("%s %s", kind, name)
+		if open == '{' {
+			token, err := decoder.Token()
+			if err != nil {
+				return syntaxError(err)
+			}
+			name = token.(string)
+			label = fmt.Sprintf("%s %q", kind, name)
+		}
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return syntaxError(err)
+		}
+		var valueOffset int64 = decoder.InputOffset() - int64(len(raw))
+
+		var entry T
+		var entryDecoder *json.Decoder = json.NewDecoder(bytes.NewReader(raw))
+		entryDecoder.DisallowUnknownFields()
+		if err := entryDecoder.Decode(&entry); err != nil {
+			return entryError(err, label, raw, valueOffset)
+		}
+		if err := each(name, &entry); err != nil {
+			return dataError(valueOffset, "%s: %v", label, err)
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return syntaxError(err)
+	}
+	return nil
+}
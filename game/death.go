@@ -0,0 +1,58 @@
+package game
+
+// :player death
+// The player entity is never destroyed. Health running out starts
+// Config.DeathTime seconds of death animation during which the world stands
+// still, after which the player respawns if a life is left and the run is
+// over otherwise. A new run is a new World, see NewWorld.
+
+// RunStats is what the game over screen shows about the run.
+type RunStats struct {
+	// ticks the player was alive for
+	Ticks       uint64
+	Kills       int32
+	Deaths      int32
+	CardsPlayed int32
+	DamageDealt int32
+	DamageTaken int32
+}
+
+// Survived is the time the player was alive for, in seconds.
+func (world *World) Survived() float32 {
+	return float32(world.Stats.Ticks) / world.Config.TickRate
+}
+
+func (world *World) killPlayer(playerEntity *Entity) {
+	world.Stats.Deaths += 1
+	world.GrabbedCard = EntityHandle{}
+	world.emit(Event{Type: EVENT_PLAYER_DIED, Entity: playerEntity.Handle, Position: playerEntity.Position})
+	world.DeathTimer = world.Config.DeathTime
+	if world.DeathTimer <= 0 {
+		world.endDeath(playerEntity)
+	}
+}
+
+// endDeath respawns the player at the world origin for a life, or ends the
+// run when there are none left.
+func (world *World) endDeath(playerEntity *Entity) {
+	world.DeathTimer = 0
+	if world.Lives <= 0 {
+		world.GameOver = true
+		world.emit(Event{Type: EVENT_GAME_OVER, Entity: playerEntity.Handle})
+		return
+	}
+
+	world.Lives -= 1
+	playerEntity.Health = playerEntity.MaxHealth
+	playerEntity.Mana = float32(playerEntity.MaxMana)
+	playerEntity.Statuses = [MAX_STATUS_COUNT]StatusEffect{}
+	// the killing hit doesn't carry over, the invulnerability of a hit is
+	// spawn protection
+	playerEntity.Velocity = Vector2{}
+	playerEntity.HitStunTimer = 0
+	playerEntity.InvulnerableTimer = playerEntity.Invulnerability
+	playerEntity.Position = Vector2{}
+	playerEntity.PreviousPosition = playerEntity.Position
+	updateCollisionRectangle(playerEntity)
+	world.emit(Event{Type: EVENT_PLAYER_RESPAWNED, Entity: playerEntity.Handle, Position: playerEntity.Position})
+}
@@ -0,0 +1,155 @@
+package game
+
+import "testing"
+
+// deathTicks is how many ticks the death animation stands the world still.
+func deathTicks(world *World) int {
+	return int(world.Config.DeathTime*world.Config.TickRate) + 1
+}
+
+func TestPlayerRespawnsWhileLivesLast(t *testing.T) {
+	world, playerEntity := testWorld()
+	world.Lives = 1
+	playerEntity.Position = Vector2{X: 40, Y: -20}
+	playerEntity.Mana = 0
+	world.applyStatus(playerEntity, "slow", 0)
+
+	// the killing hit, a push and a stun outlasting the death animation
+	playerEntity.Velocity = Vector2{X: 0, Y: 400}
+	playerEntity.HitStunTimer = 10
+
+	playerEntity.Health = 0
+	var died, respawned int = 0, 0
+	stepEvents(world, 1, InputState{}, func(event Event) {
+		if event.Type == EVENT_PLAYER_DIED {
+			died += 1
+		}
+	})
+	if died != 1 || world.DeathTimer <= 0 {
+		t.Fatalf("the player at 0 health died %d times, death timer %v", died, world.DeathTimer)
+	}
+	var tick uint64 = world.Stats.Ticks
+
+	stepEvents(world, deathTicks(world), InputState{Axis: Vector2{X: 1}}, func(event Event) {
+		if event.Type == EVENT_PLAYER_RESPAWNED {
+			respawned += 1
+		}
+	})
+	if respawned != 1 || world.Lives != 0 || world.GameOver {
+		t.Fatalf("respawned %d times with %d lives left, game over %v", respawned, world.Lives, world.GameOver)
+	}
+	if playerEntity.Health != playerEntity.MaxHealth || playerEntity.Mana != float32(playerEntity.MaxMana) {
+		t.Fatalf("respawned with %d health and %v mana", playerEntity.Health, playerEntity.Mana)
+	}
+	if playerEntity.Velocity != (Vector2{}) || playerEntity.HitStunTimer != 0 {
+		t.Fatalf("the killing hit carried over the respawn, velocity %v and stun %v", playerEntity.Velocity, playerEntity.HitStunTimer)
+	}
+	if playerEntity.InvulnerableTimer <= 0 {
+		t.Fatal("the player respawned without spawn protection")
+	}
+	if statusOf(playerEntity, "slow") != nil {
+		t.Fatal("the player respawned still slowed")
+	}
+	if world.Stats.Deaths != 1 || world.Stats.Ticks-tick > 1 {
+		t.Fatalf("stats after a death %+v, the dead ticks counted as survived", world.Stats)
+	}
+	if playerEntity.Position.X > float32(playerEntity.Speed)*world.TickDuration() {
+		t.Fatalf("the player moved while dead to %v", playerEntity.Position)
+	}
+}
+
+func TestPlayerOutOfLivesEndsTheRun(t *testing.T) {
+	world, playerEntity := testWorld()
+	world.Lives = 0
+
+	playerEntity.Health = 0
+	var gameOver int = 0
+	stepEvents(world, deathTicks(world)+1, InputState{}, func(event Event) {
+		if event.Type == EVENT_GAME_OVER {
+			gameOver += 1
+		}
+	})
+	if !world.GameOver || gameOver != 1 {
+		t.Fatalf("out of lives the run isn't over, game over %v with %d events", world.GameOver, gameOver)
+	}
+
+	var goblin *Entity = spawnAt(t, world, "goblin", Vector2{X: 50})
+	stepEvents(world, 60, InputState{Axis: Vector2{X: 1}}, func(event Event) {})
+	if goblin.Position != (Vector2{X: 50}) || playerEntity.Position != (Vector2{}) {
+		t.Fatal("the world kept moving after the game was over")
+	}
+}
+
+func TestNothingHitsOnTheTickThePlayerDies(t *testing.T) {
+	world, playerEntity := testWorld()
+	var goblin *Entity = dummyAt(t, world, Vector2{X: 50})
+	attack, err := world.spawnAttack("attack_basic", goblin, playerEntity.Position)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// already on top of the player
+	attack.Position = playerEntity.Position
+	updateCollisionRectangle(attack)
+
+	playerEntity.Health = 0
+	stepEvents(world, 1, InputState{}, func(event Event) {
+		if event.Type == EVENT_DAMAGE {
+			t.Fatalf("the dead player was hit for %d", event.Damage.Amount)
+		}
+	})
+}
+
+func TestPlayerDiesOnTheTickOfTheKillingHit(t *testing.T) {
+	world, playerEntity := testWorld()
+	world.Lives = 1
+	var goblin *Entity = dummyAt(t, world, Vector2{X: 50})
+	kill := func() {
+		attack, err := world.spawnAttack("attack_basic", goblin, playerEntity.Position)
+		if err != nil {
+			t.Fatal(err)
+		}
+		attack.Position = playerEntity.Position
+		attack.Damage = playerEntity.MaxHealth * 10
+		attack.CritChance = 0
+		updateCollisionRectangle(attack)
+		playerEntity.InvulnerableTimer = 0
+
+		var died bool = false
+		stepEvents(world, 1, InputState{}, func(event Event) {
+			if event.Type == EVENT_PLAYER_DIED {
+				died = true
+			}
+		})
+		// dead before the next tick's input could play a heal
+		if !died || world.DeathTimer <= 0 {
+			t.Fatalf("the killing hit didn't kill on its tick, death timer %v", world.DeathTimer)
+		}
+		stepEvents(world, deathTicks(world), InputState{}, func(event Event) {})
+	}
+
+	kill()
+	if world.Lives != 0 || world.GameOver || playerEntity.Health != playerEntity.MaxHealth {
+		t.Fatalf("after the first death %d lives are left, game over %v", world.Lives, world.GameOver)
+	}
+	kill()
+	if !world.GameOver {
+		t.Fatal("the second death with no lives left didn't end the run")
+	}
+}
+
+func TestSpawnersWaitWhileThePlayerIsDead(t *testing.T) {
+	var world *World = NewWorld(DefaultConfig())
+	playerEntity, _ := world.Get(world.Player)
+	playerEntity.Health = 0
+	world.Step(world.TickDuration(), InputState{})
+
+	var timers []float32 = append([]float32(nil), world.spawnTimers...)
+	for i := 0; i < deathTicks(world)-1; i++ {
+		world.Step(world.TickDuration(), InputState{})
+	}
+	for i := 0; i < len(timers); i++ {
+		if world.spawnTimers[i] != timers[i] {
+			t.Fatalf("spawner %d timer went from %v to %v while the player was dead", i, timers[i], world.spawnTimers[i])
+		}
+	}
+}
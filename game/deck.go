@@ -0,0 +1,133 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Deck is the card cycle around the hand: cards are drawn from the top of
+// DrawPile into the Hand, played cards go to DiscardPile, and once DrawPile
+// runs out the discards are shuffled back into it. Both piles hold card names
+// of Config.Cards, the top of a pile is its end.
+type Deck struct {
+	DrawPile    []string
+	DiscardPile []string
+
+	// time since the last timed draw, only runs while the hand has room
+	DrawTimer float32
+}
+
+// newDeck shuffles the starting deck into the draw pile.
+func (world *World) newDeck() {
+	world.Deck = Deck{DrawPile: startingDeck(world.Config.Cards)}
+	shuffleCards(&world.Random, world.Deck.DrawPile)
+}
+
+// startingDeck holds every card of cards as many times as its copies, in name
+// order. Map order is random and the shuffle has to start from the same order
+// to be reproduced by the seed.
+func startingDeck(cards map[string]Card) []string {
+	var names []string = make([]string, 0, len(cards))
+	for name := range cards {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var deck []string = nil
+	for _, name := range names {
+		for i := int32(0); i < cards[name].Copies; i++ {
+			deck = append(deck, name)
+		}
+	}
+	return deck
+}
+
+func shuffleCards(random *Random, cards []string) {
+	for i := len(cards) - 1; i > 0; i-- {
+		var j int = random.Intn(i + 1)
+		cards[i], cards[j] = cards[j], cards[i]
+	}
+}
+
+func (world *World) handSize() int32 {
+	return min(world.Config.HandSize, MAX_HAND_COUNT)
+}
+
+// drawCards moves up to count cards from the draw pile into the hand, stopping
+// early when the hand is full or there is nothing left to draw. Returns how
+// many were drawn.
+func (world *World) drawCards(count int32) int32 {
+	var drawn int32 = 0
+	for ; drawn < count; drawn++ {
+		if world.Hand.Stats().Live >= world.handSize() {
+			break
+		}
+		if len(world.Deck.DrawPile) == 0 {
+			world.Deck.DrawPile = append(world.Deck.DrawPile, world.Deck.DiscardPile...)
+			world.Deck.DiscardPile = world.Deck.DiscardPile[:0]
+			shuffleCards(&world.Random, world.Deck.DrawPile)
+		}
+		if len(world.Deck.DrawPile) == 0 {
+			break
+		}
+
+		var last int = len(world.Deck.DrawPile) - 1
+		var name string = world.Deck.DrawPile[last]
+		world.Deck.DrawPile = world.Deck.DrawPile[:last]
+		if _, err := world.spawnCard(name); err != nil {
+			// the card stays in the cycle
+			world.Deck.DiscardPile = append(world.Deck.DiscardPile, name)
+			break
+		}
+	}
+	return drawn
+}
+
+// spawnCard puts a card in the hand, dressed as the card's archetype.
+func (world *World) spawnCard(name string) (*Entity, error) {
+	card, ok := world.Config.Cards[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown card %q", name)
+	}
+	archetype, ok := world.Config.Archetypes[card.Archetype]
+	if !ok {
+		return nil, fmt.Errorf("card %q: unknown archetype %q", name, card.Archetype)
+	}
+
+	en, err := world.Hand.createCard()
+	if err != nil {
+		return nil, err
+	}
+	applyArchetype(en, card.Archetype, &archetype)
+	en.Card = name
+	en.Position = world.drawPilePosition()
+	en.PreviousPosition = en.Position
+	en.CardScale = 1
+	updateCardRectangle(en)
+
+	world.spawnSerial += 1
+	en.spawnSerial = world.spawnSerial
+	return en, nil
+}
+
+// discardCard takes a card out of the hand and puts it on the discard pile.
+func (world *World) discardCard(en *Entity) {
+	if !en.IsValid {
+		return
+	}
+	world.Deck.DiscardPile = append(world.Deck.DiscardPile, en.Card)
+	world.Hand.destroyCard(en)
+}
+
+// :update :deck timed draws
+func (world *World) updateDeck(delta_t float32) {
+	if world.Config.DrawInterval <= 0 || world.Hand.Stats().Live >= world.handSize() {
+		world.Deck.DrawTimer = 0
+		return
+	}
+	world.Deck.DrawTimer += delta_t
+	if world.Deck.DrawTimer >= world.Config.DrawInterval {
+		world.Deck.DrawTimer = 0
+		world.drawCards(1)
+	}
+}
@@ -0,0 +1,53 @@
+package game
+
+import "testing"
+
+// cardCount is every card of the cycle, in the piles and in the hand.
+func cardCount(world *World) int {
+	return len(world.Deck.DrawPile) + len(world.Deck.DiscardPile) + int(world.Hand.Stats().Live)
+}
+
+func TestDeckCyclesThroughTheDiscards(t *testing.T) {
+	world, playerEntity := testWorld()
+	world.Config.DrawInterval = 0
+	var total int = len(startingDeck(world.Config.Cards))
+	if world.Hand.Stats().Live != world.Config.StartingHand || cardCount(world) != total {
+		t.Fatalf("started with %d cards in hand and %d in the cycle, expected %d and %d", world.Hand.Stats().Live, cardCount(world), world.Config.StartingHand, total)
+	}
+
+	// play through more cards than the deck holds, the discards come back
+	for played := 0; played < total*2; played++ {
+		var card *Entity = firstCard(t, world)
+		playerEntity.Mana = float32(playerEntity.MaxMana)
+		if !world.playCard(card, playerEntity, Vector2{X: 100}) {
+			t.Fatalf("couldn't play %q", card.Card)
+		}
+		if len(world.Deck.DiscardPile) == 0 {
+			t.Fatal("a played card didn't go to the discard pile")
+		}
+		world.drawCards(1)
+		if cardCount(world) != total {
+			t.Fatalf("after %d cards the cycle holds %d, expected %d", played+1, cardCount(world), total)
+		}
+	}
+}
+
+func TestDeckDrawsUpToTheHandSize(t *testing.T) {
+	world, _ := testWorld()
+	world.drawCards(MAX_HAND_COUNT)
+	if world.Hand.Stats().Live != world.Config.HandSize {
+		t.Fatalf("the hand holds %d cards, the hand size is %d", world.Hand.Stats().Live, world.Config.HandSize)
+	}
+}
+
+func TestDeckDrawsOnATimer(t *testing.T) {
+	world, _ := testWorld()
+	var before int32 = world.Hand.Stats().Live
+	var ticks int = int(world.Config.DrawInterval*world.Config.TickRate) + 1
+	for i := 0; i < ticks; i++ {
+		world.Step(world.TickDuration(), InputState{})
+	}
+	if world.Hand.Stats().Live != before+1 {
+		t.Fatalf("after a draw interval the hand holds %d cards, expected %d", world.Hand.Stats().Live, before+1)
+	}
+}
@@ -0,0 +1,219 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// :enum EffectKind
+type EffectKind int
+
+const (
+	EFFECT_NIL         EffectKind = 0
+	EFFECT_PROJECTILE  EffectKind = 1
+	EFFECT_AREA_DAMAGE EffectKind = 2
+	EFFECT_HEAL        EffectKind = 3
+	EFFECT_STATUS      EffectKind = 4
+	EFFECT_DRAW        EffectKind = 5
+	EFFECT_SUMMON      EffectKind = 6
+	EFFECT_DASH        EffectKind = 7
+)
+
+var effectKindNames = map[string]EffectKind{
+	"projectile":  EFFECT_PROJECTILE,
+	"area_damage": EFFECT_AREA_DAMAGE,
+	"heal":        EFFECT_HEAL,
+	"status":      EFFECT_STATUS,
+	"draw":        EFFECT_DRAW,
+	"summon":      EFFECT_SUMMON,
+	"dash":        EFFECT_DASH,
+}
+
+// Effect is one step of playing a card. Which parameters are read depends on
+// the type:
+//
+//	projectile   archetype, an attack spawned at the caster toward the target,
+//	             its hits put status on what they hit, for duration seconds,
+//	             its behavior, when given, replaces the archetype's
+//	area_damage  amount of damage_type damage to every enemy within radius of
+//	             the target
+//	heal         amount of health back to the caster, up to its max health
+//	status       status for duration seconds, or its own duration when 0, on
+//	             every enemy within radius of the target, or on the caster
+//	             when radius is 0
+//	draw         amount of cards
+//	summon       amount entities of archetype around the target
+//	dash         the caster moves up to distance toward the target, unless
+//	             it can't move
+type Effect struct {
+	Type      string `json:"type"`
+	Archetype string `json:"archetype"`
+	Amount    int32  `json:"amount"`
+	// of area_damage
+	DamageType DamageType `json:"damage_type"`
+	Radius     float32    `json:"radius"`
+	Distance   float32    `json:"distance"`
+	Status     string     `json:"status"`
+	Duration   float32    `json:"duration"`
+	// of projectile
+	Behavior ProjectileBehavior `json:"behavior"`
+
+	Kind EffectKind `json:"-"`
+}
+
+func (effect *Effect) UnmarshalJSON(text []byte) error {
+	type plainEffect Effect
+	var plain plainEffect
+	var decoder *json.Decoder = json.NewDecoder(bytes.NewReader(text))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&plain); err != nil {
+		return err
+	}
+	*effect = Effect(plain)
+
+	kind, ok := effectKindNames[effect.Type]
+	if !ok {
+		return fmt.Errorf("unknown effect type %q", effect.Type)
+	}
+	effect.Kind = kind
+	return nil
+}
+
+func (effect *Effect) validate(archetypes map[string]Archetype, statuses map[string]Status) error {
+	if effect.Amount < 0 || effect.Radius < 0 || effect.Distance < 0 || effect.Duration < 0 {
+		return errors.New("amount, radius, distance and duration can't be negative")
+	}
+
+	switch effect.Kind {
+	case EFFECT_PROJECTILE:
+		if archetype, ok := archetypes[effect.Archetype]; !ok {
+			return fmt.Errorf("unknown archetype %q", effect.Archetype)
+		} else if archetype.ArchType != ARCH_ATTACK {
+			return fmt.Errorf("archetype %q is not an attack", effect.Archetype)
+		} else if !archetype.Projectile && effect.Behavior != (ProjectileBehavior{}) {
+			return fmt.Errorf("archetype %q is not a projectile, only a projectile has a behavior", effect.Archetype)
+		}
+		if err := effect.Behavior.validate(); err != nil {
+			return err
+		}
+		if err := effect.Behavior.validateSplit(archetypes); err != nil {
+			return err
+		}
+		if _, ok := statuses[effect.Status]; effect.Status != "" && !ok {
+			return fmt.Errorf("unknown status %q", effect.Status)
+		}
+	case EFFECT_SUMMON:
+		if _, ok := archetypes[effect.Archetype]; !ok {
+			return fmt.Errorf("unknown archetype %q", effect.Archetype)
+		}
+		if effect.Amount == 0 {
+			return errors.New("summons nothing, amount is 0")
+		}
+	case EFFECT_AREA_DAMAGE:
+		if effect.Amount == 0 || effect.Radius == 0 {
+			return errors.New("needs an amount of damage and a radius")
+		}
+	case EFFECT_HEAL, EFFECT_DRAW:
+		if effect.Amount == 0 {
+			return errors.New("does nothing, amount is 0")
+		}
+	case EFFECT_STATUS:
+		if status, ok := statuses[effect.Status]; !ok {
+			return fmt.Errorf("unknown status %q", effect.Status)
+		} else if effect.Duration == 0 && status.Duration == 0 {
+			return errors.New("needs a duration, the status has none of its own")
+		}
+	case EFFECT_DASH:
+		if effect.Distance == 0 {
+			return errors.New("needs a distance")
+		}
+	}
+	return nil
+}
+
+// :effects runner
+
+// runEffects plays effects in order for caster aiming at target. An effect
+// that can't happen, a projectile in a full world or a heal at full health, is
+// skipped and the rest still run. card is the card played, the Damage and
+// Width it has override those of the projectiles it spawns.
+func (world *World) runEffects(effects []Effect, caster *Entity, target Vector2, card *Entity) {
+	for i := 0; i < len(effects); i++ {
+		var effect *Effect = &effects[i]
+		switch effect.Kind {
+
+		case EFFECT_PROJECTILE:
+			attack, err := world.spawnAttack(effect.Archetype, caster, target)
+			if err != nil {
+				continue
+			}
+			if card.Damage > 0 {
+				attack.Damage = card.Damage
+			}
+			if card.Width > 0 {
+				attack.Width = card.Width
+			}
+			attack.Inflicts = effect.Status
+			attack.InflictDuration = effect.Duration
+			if effect.Behavior != (ProjectileBehavior{}) {
+				attack.Behavior = effect.Behavior
+			}
+
+		case EFFECT_AREA_DAMAGE:
+			world.forTargetsInRadius(caster.Faction, caster.Handle, target, effect.Radius, func(en *Entity) {
+				world.applyDamage(caster, en, effect.Amount, effect.DamageType)
+			})
+
+		case EFFECT_HEAL:
+			caster.Health = min(caster.Health+effect.Amount, max(caster.MaxHealth, caster.Health))
+
+		case EFFECT_STATUS:
+			if effect.Radius == 0 {
+				world.applyStatus(caster, effect.Status, effect.Duration)
+				continue
+			}
+			world.forTargetsInRadius(caster.Faction, caster.Handle, target, effect.Radius, func(en *Entity) {
+				world.applyStatus(en, effect.Status, effect.Duration)
+			})
+
+		case EFFECT_DRAW:
+			world.drawCards(effect.Amount)
+
+		case EFFECT_SUMMON:
+			for j := int32(0); j < effect.Amount; j++ {
+				var position Vector2 = world.jitterSpawnPosition(target, effect.Radius)
+				summoned, err := world.spawnArchetype(effect.Archetype, position)
+				if err != nil {
+					break
+				}
+				// fights for whoever summoned it
+				summoned.Faction = caster.Faction
+			}
+
+		case EFFECT_DASH:
+			// rooted or stunned the caster stays put
+			if world.speedMultiplier(caster) == 0 {
+				continue
+			}
+			var toTarget Vector2 = Vector2Subtract(target, caster.Position)
+			var distance float32 = min(effect.Distance, Vector2Length(toTarget))
+			caster.Position = Vector2Add(caster.Position, Vector2Scale(Vector2Normalize(toTarget), distance))
+			updateCollisionRectangle(caster)
+		}
+	}
+}
+
+// forTargetsInRadius calls each for everything within radius of center that
+// faction, on behalf of owner, can hurt. It queries the spatial hash as the
+// last rebuild left it, entities don't move between the rebuilds of a tick and
+// those of the effects.
+func (world *World) forTargetsInRadius(faction Faction, owner EntityHandle, center Vector2, radius float32, each func(en *Entity)) {
+	world.queryBuffer = world.QueryCircle(center, radius, world.queryBuffer[:0])
+	for _, handle := range world.queryBuffer {
+		if en, ok := world.Get(handle); ok && world.canHurt(faction, owner, en) {
+			each(en)
+		}
+	}
+}
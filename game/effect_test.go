@@ -0,0 +1,60 @@
+package game
+
+import "testing"
+
+// projectileAt finds the attack_fireball in the world, failing when there is
+// none.
+func projectileAt(t *testing.T, world *World) Vector2 {
+	for i := 0; i < MAX_ENTITY_COUNT; i++ {
+		if world.Entities[i].IsValid && world.Entities[i].Archetype == "attack_fireball" {
+			return world.Entities[i].Position
+		}
+	}
+	t.Fatal("no projectile was spawned")
+	return Vector2{}
+}
+
+func TestEffectsRunInOrder(t *testing.T) {
+	var dash Effect = Effect{Kind: EFFECT_DASH, Distance: 40}
+	var projectile Effect = Effect{Kind: EFFECT_PROJECTILE, Archetype: "attack_fireball"}
+	var target Vector2 = Vector2{X: 100}
+
+	world, playerEntity := testWorld()
+	world.runEffects([]Effect{dash, projectile}, playerEntity, target, &Entity{})
+	if position := projectileAt(t, world); position != (Vector2{X: 40}) {
+		t.Fatalf("dash then projectile fired from %v, expected from where the dash ended", position)
+	}
+
+	world, playerEntity = testWorld()
+	world.runEffects([]Effect{projectile, dash}, playerEntity, target, &Entity{})
+	if position := projectileAt(t, world); position != (Vector2{}) {
+		t.Fatalf("projectile then dash fired from %v, expected from where the caster stood", position)
+	}
+}
+
+func TestEffectSkippedTheRestStillRun(t *testing.T) {
+	world, playerEntity := testWorld()
+	var cards int32 = world.Hand.Stats().Live
+	// the player is at full health, the heal has nothing to do
+	world.runEffects([]Effect{{Kind: EFFECT_HEAL, Amount: 20}, {Kind: EFFECT_DRAW, Amount: 1}}, playerEntity, Vector2{}, &Entity{})
+	if playerEntity.Health != world.Config.Archetypes["player"].Health {
+		t.Fatalf("healed the player past its archetype's health to %d", playerEntity.Health)
+	}
+	if world.Hand.Stats().Live != cards+1 {
+		t.Fatal("the draw after a heal with nothing to heal didn't run")
+	}
+}
+
+func TestCardDrawSeesTheRoomItLeaves(t *testing.T) {
+	world, playerEntity := testWorld()
+	world.drawCards(MAX_HAND_COUNT)
+	var card *Entity = firstCard(t, world)
+	world.Config.Cards[card.Card] = Card{Archetype: card.Archetype, Effects: []Effect{{Kind: EFFECT_DRAW, Amount: 1}}}
+
+	if !world.playCard(card, playerEntity, Vector2{}) {
+		t.Fatal("couldn't play the card")
+	}
+	if world.Hand.Stats().Live != world.Config.HandSize {
+		t.Fatalf("a draw card played from a full hand left %d cards, expected the hand full again", world.Hand.Stats().Live)
+	}
+}
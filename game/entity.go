@@ -0,0 +1,222 @@
+package game
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"strings"
+)
+
+// :globals constants
+const (
+	MAX_ENTITY_COUNT               = 1024
+	MAX_HAND_COUNT                 = 40
+	entitySelectionRadius  float32 = 10.0
+	PLAYER_MOVEMENT_RADIUS float32 = 1
+)
+
+// :enum EntityArchType
+type EntityArchType int
+
+const (
+	ARCH_NIL           EntityArchType = 0
+	ARCH_TROLL         EntityArchType = 1
+	ARCH_GOBLIN        EntityArchType = 2
+	ARCH_PLAYER        EntityArchType = 3
+	ARCH_CARD_FIREBALL EntityArchType = 4
+	ARCH_CARD          EntityArchType = 5
+	ARCH_ATTACK        EntityArchType = 6
+	// enemies defined only in data, behaving like goblins and trolls
+	ARCH_ENEMY EntityArchType = 7
+)
+
+var archTypeNames = map[string]EntityArchType{
+	"troll":  ARCH_TROLL,
+	"goblin": ARCH_GOBLIN,
+	"player": ARCH_PLAYER,
+	"card":   ARCH_CARD,
+	"attack": ARCH_ATTACK,
+	"enemy":  ARCH_ENEMY,
+}
+
+func isEnemy(en *Entity) bool {
+	return en.Type == ARCH_TROLL || en.Type == ARCH_GOBLIN || en.Type == ARCH_ENEMY
+}
+
+// :enum SpriteId
+type SpriteId int
+
+const (
+	SPRITE_NIL SpriteId = iota
+	SPRITE_PLAYER
+	SPRITE_GOBLIN
+	SPRITE_TROLL
+	SPRITE_CARD_FIREBALL
+	SPRITE_ATTACK_FIREBALL
+	SPRITE_ATTACK_BASIC
+	SPRITE_ATTACK_SWORD
+	SPRITE_MAX
+)
+
+// SpriteSize is the only part of a sprite the simulation needs: collision
+// rectangles are sized from it.
+type SpriteSize struct {
+	Width  int32
+	Height int32
+}
+
+type spriteInfo struct {
+	Name string
+	Path string
+	Size SpriteSize
+}
+
+// :globals sprites
+// indexed by SpriteId, the built in sprites come first and archetype data can
+// register more by image path. Default sizes match the images in ./resources,
+// the renderer overrides them with the loaded texture sizes through
+// SetSpriteSize.
+var sprites = []spriteInfo{
+	SPRITE_NIL:             {},
+	SPRITE_PLAYER:          {Name: "player", Path: "./resources/player.png", Size: SpriteSize{Width: 10, Height: 19}},
+	SPRITE_GOBLIN:          {Name: "goblin", Path: "./resources/goblin.png", Size: SpriteSize{Width: 8, Height: 14}},
+	SPRITE_TROLL:           {Name: "troll", Path: "./resources/troll.png", Size: SpriteSize{Width: 8, Height: 14}},
+	SPRITE_CARD_FIREBALL:   {Name: "card_fireball", Path: "./resources/card_fireball.png", Size: SpriteSize{Width: 12, Height: 16}},
+	SPRITE_ATTACK_FIREBALL: {Name: "attack_fireball", Path: "./resources/attack_fireball.png", Size: SpriteSize{Width: 14, Height: 13}},
+	SPRITE_ATTACK_BASIC:    {Name: "attack_basic", Path: "./resources/basic_attack.png", Size: SpriteSize{Width: 5, Height: 7}},
+	SPRITE_ATTACK_SWORD:    {Name: "attack_sword", Path: "./resources/sword.png", Size: SpriteSize{Width: 7, Height: 11}},
+}
+
+type Entity struct {
+	Handle             EntityHandle
+	Archetype          string
+	Position           Vector2
+	PreviousPosition   Vector2
+	IsValid            bool
+	Type               EntityArchType
+	SpriteId           SpriteId `json:"-"` // not saved, see saveEntity
+	Health             int32
+	Faction            Faction
+	MaxHealth          int32
+	inputAxis          Vector2
+	CollisionRectangle Rectangle
+
+	// cards cost mana, which regenerates ManaRegen per second up to MaxMana
+	Mana      float32
+	MaxMana   int32
+	ManaRegen float32
+
+	Statuses [MAX_STATUS_COUNT]StatusEffect
+
+	// pushed by hits, decays over time, see :damage knockback. The timers
+	// are the seconds left of hit stun and invulnerability. LastHitBy is who
+	// was behind the last hit that landed, the owner for an attack, and is
+	// credited with the kill
+	Velocity          Vector2
+	HitStunTimer      float32
+	InvulnerableTimer float32
+	LastHitBy         EntityHandle
+
+	DamageStats
+
+	// for enemies, AITimer is the time spent in AIState and AttackCooldown
+	// the time until it may wind up again
+	AI             AIConfig
+	AIState        AIState
+	AITimer        float32
+	AttackCooldown float32
+
+	// for cards, Card names the definition in Config.Cards
+	Card      string
+	CardScale float32
+	Range     int32
+	Width     int32
+	Damage    int32
+	Speed     int32
+
+	// for attacks, Owner spawned it and its Faction is the owner's, a hit
+	// puts the target under the Inflicts status
+	Owner           EntityHandle
+	Inflicts        string
+	InflictDuration float32
+	HitTargets      [MAX_HIT_TARGETS]EntityHandle
+	MaxPosition     Vector2
+	CenterPosition  Vector2
+	Angle           float32
+	MaxAngle        float32
+	isMelee         bool
+	Radius          float32
+	isProjectile    bool
+	// a projectile's Behavior counts down its pierces and bounces as they
+	// are used, ProjectileSpeed starts at Speed and changes by its
+	// acceleration
+	Behavior        ProjectileBehavior
+	ProjectileSpeed float32
+
+	detonateOnImpact   bool
+	detonateAtMaxRange bool
+	Falloff            float32
+
+	// order of creation, used to find the oldest entity when recycling
+	spawnSerial uint64
+}
+
+func getSpriteSize(id SpriteId) *SpriteSize {
+	if id >= 0 && int(id) < len(sprites) {
+		return &sprites[id].Size
+	}
+	return &sprites[0].Size
+}
+
+func GetSpriteSize(id SpriteId) SpriteSize {
+	return *getSpriteSize(id)
+}
+
+func SetSpriteSize(id SpriteId, width, height int32) {
+	if id > SPRITE_NIL && int(id) < len(sprites) {
+		sprites[id].Size = SpriteSize{Width: width, Height: height}
+	}
+}
+
+// SpriteCount is one past the highest registered SpriteId.
+func SpriteCount() SpriteId {
+	return SpriteId(len(sprites))
+}
+
+func SpritePath(id SpriteId) string {
+	if id > SPRITE_NIL && int(id) < len(sprites) {
+		return sprites[id].Path
+	}
+	return ""
+}
+
+// spriteByNameOrPath resolves a built in sprite name, or registers the image at
+// path the first time it is seen, sized from the image header.
+func spriteByNameOrPath(name string) (SpriteId, error) {
+	for id := SPRITE_NIL + 1; int(id) < len(sprites); id++ {
+		if sprites[id].Name == name || sprites[id].Path == name {
+			return id, nil
+		}
+	}
+
+	if !strings.HasSuffix(name, ".png") {
+		return SPRITE_NIL, fmt.Errorf("unknown sprite %q, use a built in sprite name or a .png path", name)
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return SPRITE_NIL, err
+	}
+	defer file.Close()
+
+	imageConfig, err := png.DecodeConfig(file)
+	if err != nil {
+		return SPRITE_NIL, fmt.Errorf("sprite %s: %w", name, err)
+	}
+
+	sprites = append(sprites, spriteInfo{
+		Path: name,
+		Size: SpriteSize{Width: int32(imageConfig.Width), Height: int32(imageConfig.Height)},
+	})
+	return SpriteId(len(sprites) - 1), nil
+}
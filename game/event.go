@@ -0,0 +1,46 @@
+package game
+
+// :enum EventType
+type EventType int
+
+const (
+	EVENT_NIL          EventType = 0
+	EVENT_CARD_PLAYED  EventType = 1
+	EVENT_CAST_REFUSED EventType = 2
+	EVENT_EXPLOSION    EventType = 3
+	// an enemy's AI changed state
+	EVENT_AI_TRANSITION EventType = 4
+	// Entity took a hit, at Position
+	EVENT_DAMAGE           EventType = 5
+	EVENT_PLAYER_DIED      EventType = 6
+	EVENT_PLAYER_RESPAWNED EventType = 7
+	// the player died without a life left, World.GameOver is set
+	EVENT_GAME_OVER EventType = 8
+)
+
+// Event is something that happened during a tick that the renderer or a test
+// may want to react to, the simulation itself never reads them back.
+type Event struct {
+	Type EventType
+	Tick uint64
+	// the entity the event is about, for cast events the caster
+	Entity EntityHandle
+	Card   string
+	// the status a cast was refused for, empty when it was the mana
+	Status string
+
+	// where and how big, for explosions
+	Position Vector2
+	Radius   float32
+
+	// for AI transitions
+	From AIState
+	To   AIState
+
+	Damage DamageResult
+}
+
+func (world *World) emit(event Event) {
+	event.Tick = world.Tick
+	world.Events = append(world.Events, event)
+}
@@ -0,0 +1,113 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// :enum Faction
+// Every entity belongs to a faction, an attack to that of its owner. Who hurts
+// whom is decided by Config.Relations between the two factions, not by what
+// the entities are.
+type Faction int
+
+const (
+	// cards and whatever isn't in the fight, neutral to everyone
+	FACTION_NONE    Faction = 0
+	FACTION_PLAYER  Faction = 1
+	FACTION_MONSTER Faction = 2
+	// the other side of a duel
+	FACTION_RIVAL Faction = 3
+	FACTION_COUNT int     = 4
+)
+
+var factionNames = map[string]Faction{
+	"":        FACTION_NONE,
+	"none":    FACTION_NONE,
+	"player":  FACTION_PLAYER,
+	"monster": FACTION_MONSTER,
+	"rival":   FACTION_RIVAL,
+}
+
+// factions are written by name so saves and replays read back through
+// UnmarshalJSON like the data files
+func (faction Faction) MarshalJSON() ([]byte, error) {
+	for name, found := range factionNames {
+		if found == faction && name != "" {
+			return json.Marshal(name)
+		}
+	}
+	return nil, fmt.Errorf("unknown faction %d", int(faction))
+}
+
+func (faction *Faction) UnmarshalJSON(text []byte) error {
+	var name string
+	if err := json.Unmarshal(text, &name); err != nil {
+		return err
+	}
+	found, ok := factionNames[name]
+	if !ok {
+		return fmt.Errorf("unknown faction %q", name)
+	}
+	*faction = found
+	return nil
+}
+
+// :enum Relation
+type Relation int
+
+const (
+	RELATION_NEUTRAL Relation = 0
+	RELATION_HOSTILE Relation = 1
+	RELATION_ALLIED  Relation = 2
+)
+
+// Relations is how each faction sees each other, indexed by Faction and kept
+// symmetric by SetRelation.
+type Relations [FACTION_COUNT][FACTION_COUNT]Relation
+
+func (relations *Relations) SetRelation(a Faction, b Faction, relation Relation) {
+	relations[a][b] = relation
+	relations[b][a] = relation
+}
+
+func (relations *Relations) Of(a Faction, b Faction) Relation {
+	if a < 0 || int(a) >= FACTION_COUNT || b < 0 || int(b) >= FACTION_COUNT {
+		return RELATION_NEUTRAL
+	}
+	return relations[a][b]
+}
+
+// defaultRelations: every faction is allied with itself, the player, the
+// monsters and a rival are all hostile to each other
+func defaultRelations() Relations {
+	var relations Relations
+	for faction := FACTION_PLAYER; int(faction) < FACTION_COUNT; faction++ {
+		relations.SetRelation(faction, faction, RELATION_ALLIED)
+	}
+	relations.SetRelation(FACTION_PLAYER, FACTION_MONSTER, RELATION_HOSTILE)
+	relations.SetRelation(FACTION_PLAYER, FACTION_RIVAL, RELATION_HOSTILE)
+	relations.SetRelation(FACTION_MONSTER, FACTION_RIVAL, RELATION_HOSTILE)
+	return relations
+}
+
+// canHurt is true when something of faction spawned by owner damages target.
+// Allies only hurt each other with Config.FriendlyFire on, and nothing hurts
+// its own owner.
+func (world *World) canHurt(faction Faction, owner EntityHandle, target *Entity) bool {
+	if target.Type == ARCH_ATTACK || target.Type == ARCH_CARD || target.Handle == owner {
+		return false
+	}
+	switch world.Config.Relations.Of(faction, target.Faction) {
+	case RELATION_HOSTILE:
+		return true
+	case RELATION_ALLIED:
+		return world.Config.FriendlyFire
+	}
+	return false
+}
+
+// canHit is canHurt for an attack, which fights for the faction of its owner.
+func (world *World) canHit(attack *Entity, target *Entity) bool {
+	return world.canHurt(attack.Faction, attack.Owner, target)
+}
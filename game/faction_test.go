@@ -0,0 +1,110 @@
+package game
+
+import "testing"
+
+func TestDefaultRelations(t *testing.T) {
+	var relations Relations = defaultRelations()
+	var tests = []struct {
+		a, b     Faction
+		expected Relation
+	}{
+		{FACTION_PLAYER, FACTION_PLAYER, RELATION_ALLIED},
+		{FACTION_MONSTER, FACTION_MONSTER, RELATION_ALLIED},
+		{FACTION_RIVAL, FACTION_RIVAL, RELATION_ALLIED},
+		{FACTION_PLAYER, FACTION_MONSTER, RELATION_HOSTILE},
+		{FACTION_PLAYER, FACTION_RIVAL, RELATION_HOSTILE},
+		{FACTION_MONSTER, FACTION_RIVAL, RELATION_HOSTILE},
+		{FACTION_NONE, FACTION_NONE, RELATION_NEUTRAL},
+		{FACTION_NONE, FACTION_PLAYER, RELATION_NEUTRAL},
+		{FACTION_NONE, FACTION_MONSTER, RELATION_NEUTRAL},
+		// out of the table is nobody's business
+		{Faction(FACTION_COUNT), FACTION_PLAYER, RELATION_NEUTRAL},
+	}
+	for _, test := range tests {
+		if relation := relations.Of(test.a, test.b); relation != test.expected {
+			t.Errorf("%d to %d is %d, expected %d", test.a, test.b, relation, test.expected)
+		}
+		if relation := relations.Of(test.b, test.a); relation != test.expected {
+			t.Errorf("%d to %d is %d, the table isn't symmetric", test.b, test.a, relation)
+		}
+	}
+}
+
+func TestCanHurt(t *testing.T) {
+	world, playerEntity := testWorld()
+	var goblin *Entity = dummyAt(t, world, Vector2{X: 50})
+	var troll *Entity = spawnAt(t, world, "troll", Vector2{X: -50})
+	var card *Entity = firstCard(t, world)
+
+	var tests = []struct {
+		name         string
+		faction      Faction
+		owner        *Entity
+		target       *Entity
+		friendlyFire bool
+		expected     bool
+	}{
+		{"player hits monster", FACTION_PLAYER, playerEntity, goblin, false, true},
+		{"monster hits player", FACTION_MONSTER, troll, playerEntity, false, true},
+		{"monster spares monster", FACTION_MONSTER, troll, goblin, false, false},
+		{"friendly fire", FACTION_MONSTER, troll, goblin, true, true},
+		{"never the owner", FACTION_MONSTER, troll, troll, true, false},
+		{"never a card", FACTION_PLAYER, playerEntity, card, true, false},
+		{"neutral hits nobody", FACTION_NONE, goblin, playerEntity, true, false},
+	}
+	for _, test := range tests {
+		world.Config.FriendlyFire = test.friendlyFire
+		if hurts := world.canHurt(test.faction, test.owner.Handle, test.target); hurts != test.expected {
+			t.Errorf("%s: canHurt is %v", test.name, hurts)
+		}
+	}
+}
+
+func TestFriendlyFireLetsEnemiesHurtEachOther(t *testing.T) {
+	world, _ := aiWorld(t, "troll", Vector2{X: 60})
+	world.Config.FriendlyFire = true
+	var bystander *Entity = dummyAt(t, world, Vector2{X: 5, Y: 10})
+
+	stepAI(world, bystander, 180)
+	if bystander.Health == 1000 {
+		t.Fatal("with friendly fire on the troll's slam spared the goblin next to the player")
+	}
+
+	// the same slam with friendly fire off
+	world, _ = aiWorld(t, "troll", Vector2{X: 60})
+	bystander = dummyAt(t, world, Vector2{X: 5, Y: 10})
+	stepAI(world, bystander, 180)
+	if bystander.Health != 1000 {
+		t.Fatal("with friendly fire off the troll's slam hurt the goblin next to the player")
+	}
+}
+
+func TestKillsAreCreditedToTheAttacksOwner(t *testing.T) {
+	world, playerEntity := testWorld()
+	world.Config.FriendlyFire = true
+	var goblin *Entity = dummyAt(t, world, Vector2{X: 100})
+	kill := func(owner *Entity, position Vector2) {
+		var victim *Entity = dummyAt(t, world, position)
+		victim.Health = 1
+		attack, err := world.spawnAttack("attack_basic", owner, victim.Position)
+		if err != nil {
+			t.Fatal(err)
+		}
+		attack.Position = victim.Position
+		updateCollisionRectangle(attack)
+		stepEvents(world, 2, InputState{}, func(event Event) {})
+		if victim.IsValid {
+			t.Fatal("the victim survived its killing hit")
+		}
+	}
+
+	// a goblin killing another goblin under friendly fire isn't the player's kill
+	kill(goblin, Vector2{X: 100, Y: 20})
+	if world.Stats.Kills != 0 {
+		t.Fatalf("a goblin's kill was credited to the player, %d kills", world.Stats.Kills)
+	}
+	kill(playerEntity, Vector2{X: -20})
+	if world.Stats.Kills != 1 {
+		t.Fatalf("the player's kill wasn't credited, %d kills", world.Stats.Kills)
+	}
+}
@@ -0,0 +1,114 @@
+package game
+
+// :hand layout
+// The hand is laid out in screen pixels, fanned along the bottom of the
+// screen, so it looks the same at any camera zoom and is hit tested with the
+// mouse's screen position. For cards in the hand Position is the screen
+// center of the card, Angle its tilt in degrees and CollisionRectangle its
+// screen rectangle.
+const (
+	// a card is drawn this many times its sprite size
+	HAND_CARD_SCALE float32 = 4
+	// and grows this much more while hovered
+	HAND_HOVER_SCALE float32 = 1.4
+	// how far the hovered card rises out of the fan
+	HAND_HOVER_RISE float32 = 24
+	// tilt between neighbouring cards, degrees
+	HAND_FAN_DEGREES float32 = 5
+	// the fan never gets wider than this part of the screen
+	HAND_FAN_WIDTH float32 = 0.6
+)
+
+// HandAreaTop is the screen y where the hand area, the bottom 20% of the
+// screen, starts. A card dropped above it is played, below it goes back to
+// the hand.
+func (world *World) HandAreaTop() float32 {
+	return float32(world.Config.ScreenHeight) - (float32(world.Config.ScreenHeight) * 0.20)
+}
+
+// drawPilePosition is where drawn cards fly in from, the bottom right corner.
+func (world *World) drawPilePosition() Vector2 {
+	return Vector2{X: float32(world.Config.ScreenWidth), Y: float32(world.Config.ScreenHeight)}
+}
+
+// cardSlotTarget is where the card at slot of count cards rests in the fan.
+func (world *World) cardSlotTarget(card *Entity, slot int32, count int32, hovered bool) (Vector2, float32, float32) {
+	var sprite *SpriteSize = getSpriteSize(card.SpriteId)
+	var cardWidth float32 = float32(sprite.Width) * HAND_CARD_SCALE
+	var cardHeight float32 = float32(sprite.Height) * HAND_CARD_SCALE
+	var screenWidth float32 = float32(world.Config.ScreenWidth)
+	var screenHeight float32 = float32(world.Config.ScreenHeight)
+
+	var spacing float32 = cardWidth * 0.8
+	if count > 1 {
+		spacing = min(spacing, screenWidth*HAND_FAN_WIDTH/float32(count-1))
+	}
+
+	// offset from the middle of the fan, in cards
+	var offset float32 = float32(slot) - float32(count-1)/2
+	var position Vector2 = Vector2{
+		X: screenWidth/2 + offset*spacing,
+		// the fan curves down toward its ends
+		Y: screenHeight - cardHeight*0.4 + offset*offset*2,
+	}
+	var angle float32 = offset * HAND_FAN_DEGREES
+	var scale float32 = 1
+
+	if hovered {
+		position.Y -= HAND_HOVER_RISE
+		angle = 0
+		scale = HAND_HOVER_SCALE
+	}
+	return position, angle, scale
+}
+
+func updateCardRectangle(card *Entity) {
+	var sprite *SpriteSize = getSpriteSize(card.SpriteId)
+	var width float32 = float32(sprite.Width) * HAND_CARD_SCALE * card.CardScale
+	var height float32 = float32(sprite.Height) * HAND_CARD_SCALE * card.CardScale
+	card.CollisionRectangle = Rectangle{X: card.Position.X - width/2, Y: card.Position.Y - height/2, Width: width, Height: height}
+}
+
+// cardUnderMouse is the hand card the mouse is over. The card hovered last
+// tick is drawn on top of its neighbours so it wins while the mouse stays on
+// it, otherwise the later slot, drawn over the earlier one, does.
+func (world *World) cardUnderMouse(mouseScreen Vector2, hoveredLastTick EntityHandle) EntityHandle {
+	if card, ok := world.Hand.Get(hoveredLastTick); ok && card.Handle != world.GrabbedCard && CheckCollisionPointRec(mouseScreen, card.CollisionRectangle) {
+		return card.Handle
+	}
+
+	var found EntityHandle = EntityHandle{}
+	for i := 0; i < MAX_HAND_COUNT; i++ {
+		var card *Entity = &world.Hand.Cards[i]
+		if card.IsValid && card.Handle != world.GrabbedCard && CheckCollisionPointRec(mouseScreen, card.CollisionRectangle) {
+			found = card.Handle
+		}
+	}
+	return found
+}
+
+// updateHand eases every card toward its place in the fan, and the grabbed
+// one onto the mouse. A card let go below HandAreaTop eases back the same way.
+func (world *World) updateHand(delta_t float32, mouseScreen Vector2) {
+	var count int32 = world.Hand.Stats().Live
+	var slot int32 = 0
+	for i := 0; i < MAX_HAND_COUNT; i++ {
+		var card *Entity = &world.Hand.Cards[i]
+		if !card.IsValid {
+			continue
+		}
+
+		if card.Handle == world.GrabbedCard {
+			card.Position = mouseScreen
+			animateF32ToTarget(&card.Angle, 0, delta_t, 30)
+			animateF32ToTarget(&card.CardScale, 1, delta_t, 30)
+		} else {
+			position, angle, scale := world.cardSlotTarget(card, slot, count, card.Handle == world.Frame.HoveredCard)
+			animateV2ToTarget(&card.Position, position, delta_t, 20)
+			animateF32ToTarget(&card.Angle, angle, delta_t, 20)
+			animateF32ToTarget(&card.CardScale, scale, delta_t, 30)
+		}
+		updateCardRectangle(card)
+		slot += 1
+	}
+}
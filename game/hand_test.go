@@ -0,0 +1,97 @@
+package game
+
+import "testing"
+
+// settleHand steps until the cards have eased into the fan.
+func settleHand(world *World, in InputState) {
+	for i := 0; i < 60; i++ {
+		world.Step(world.TickDuration(), in)
+	}
+}
+
+// handCards are the cards of the hand in slot order.
+func handCards(world *World) []*Entity {
+	var cards []*Entity = nil
+	for i := 0; i < MAX_HAND_COUNT; i++ {
+		if world.Hand.Cards[i].IsValid {
+			cards = append(cards, &world.Hand.Cards[i])
+		}
+	}
+	return cards
+}
+
+func TestHandHitTestsInScreenSpace(t *testing.T) {
+	world, playerEntity := testWorld()
+	world.Config.DrawInterval = 0
+	// far from the origin, world and screen positions are nowhere near
+	playerEntity.Position = Vector2{X: 5000, Y: 5000}
+	var away InputState = InputState{MouseScreen: Vector2{X: -100, Y: -100}, MouseWorld: Vector2{X: 5000, Y: 5000}}
+	settleHand(world, away)
+
+	var cards []*Entity = handCards(world)
+	if len(cards) < 2 {
+		t.Fatal("the hand needs at least two cards to test the fan")
+	}
+	for slot, card := range cards {
+		var in InputState = away
+		in.MouseScreen = card.Position
+		world.Step(world.TickDuration(), in)
+		if world.Frame.HoveredCard != card.Handle {
+			t.Fatalf("the mouse on the center of the card in slot %d hovers %v", slot, world.Frame.HoveredCard)
+		}
+		if card.Position.Y < world.HandAreaTop() || card.Position.Y > float32(world.Config.ScreenHeight) {
+			t.Fatalf("the card in slot %d rests outside the hand area at %v", slot, card.Position)
+		}
+	}
+
+	world.Step(world.TickDuration(), away)
+	if world.Frame.HoveredCard != (EntityHandle{}) {
+		t.Fatal("a card is hovered with the mouse off the screen")
+	}
+}
+
+func TestHandHoveredCardWinsTheOverlap(t *testing.T) {
+	world, _ := testWorld()
+	world.Config.DrawInterval = 0
+	var away InputState = InputState{MouseScreen: Vector2{X: -100, Y: -100}}
+	settleHand(world, away)
+
+	var cards []*Entity = handCards(world)
+	var left, right *Entity = cards[0], cards[1]
+	// a point both cards cover, the right one is drawn on top
+	var overlap Vector2 = Vector2{X: right.CollisionRectangle.X + 1, Y: right.Position.Y}
+	if !CheckCollisionPointRec(overlap, left.CollisionRectangle) {
+		t.Fatal("the cards don't overlap, the test proves nothing")
+	}
+
+	world.Step(world.TickDuration(), InputState{MouseScreen: overlap})
+	if world.Frame.HoveredCard != right.Handle {
+		t.Fatal("coming from outside the hand, the card on top isn't the one hovered")
+	}
+
+	// moving over from the left card, it stays hovered
+	settleHand(world, InputState{MouseScreen: left.Position})
+	world.Step(world.TickDuration(), InputState{MouseScreen: overlap})
+	if world.Frame.HoveredCard != left.Handle {
+		t.Fatal("the hovered card lost the mouse to its neighbour while the mouse was still on it")
+	}
+}
+
+func TestHandGrabsWithTheScreenMouse(t *testing.T) {
+	world, _ := testWorld()
+	var away InputState = InputState{MouseScreen: Vector2{X: -100, Y: -100}}
+	settleHand(world, away)
+	var card *Entity = handCards(world)[0]
+
+	var grab InputState = InputState{MouseScreen: card.Position, MouseWorld: Vector2{X: -1000}, MouseLeftDown: true, MouseLeftPressed: true}
+	world.Step(world.TickDuration(), grab)
+	if world.GrabbedCard != card.Handle {
+		t.Fatal("pressing on a card didn't grab it")
+	}
+
+	var drag InputState = InputState{MouseScreen: Vector2{X: 300, Y: 100}, MouseLeftDown: true}
+	world.Step(world.TickDuration(), drag)
+	if card.Position != drag.MouseScreen {
+		t.Fatalf("the grabbed card is at %v, the mouse at %v", card.Position, drag.MouseScreen)
+	}
+}
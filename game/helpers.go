@@ -0,0 +1,49 @@
+package game
+
+import (
+	"fmt"
+	"math"
+)
+
+// :helpers :engine functions
+func assert(condition bool, error string) {
+	if condition == false {
+		fmt.Print(error)
+		panic("")
+	}
+}
+
+func almostEquals(a, b, epsilon float32) bool {
+	return float32(math.Abs(float64(a-b))) <= epsilon
+}
+
+func animateF32ToTarget(value *float32, target, delta_t, rate float32) bool {
+	*value += (target - *value) * (1.0 - float32(math.Pow(2.0, float64(-rate*delta_t))))
+	if almostEquals(*value, target, 0.001) {
+		*value = target
+		return true
+	}
+
+	return false
+}
+
+func animateV2ToTarget(value *Vector2, target Vector2, delta_t, rate float32) {
+	animateF32ToTarget(&value.X, target.X, delta_t, rate)
+	animateF32ToTarget(&value.Y, target.Y, delta_t, rate)
+}
+
+const TILE_WIDTH int32 = 8
+
+func WorldPositionToTilePosition(worldPosition float32) float32 {
+	return float32(math.Round(float64(worldPosition) / float64(TILE_WIDTH)))
+}
+
+func TilePositionToWorldPosition(tilePosition float32) float32 {
+	return float32(tilePosition) * float32(TILE_WIDTH)
+}
+
+func RoundV2ToTile(worldPosition Vector2) Vector2 {
+	worldPosition.X = TilePositionToWorldPosition(WorldPositionToTilePosition(worldPosition.X))
+	worldPosition.Y = TilePositionToWorldPosition(WorldPositionToTilePosition(worldPosition.Y))
+	return worldPosition
+}
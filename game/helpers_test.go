@@ -0,0 +1,59 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// randomInput is what a player mashing keys and the mouse could send in a
+// tick, mostly moving and sometimes dragging a card out of the hand.
+func randomInput(world *World, random *rand.Rand) InputState {
+	var in InputState
+	in.Axis = Vector2{X: float32(random.Intn(3) - 1), Y: float32(random.Intn(3) - 1)}
+	in.Running = random.Intn(4) == 0
+	in.MouseScreen = Vector2{X: random.Float32() * float32(world.Config.ScreenWidth), Y: random.Float32() * float32(world.Config.ScreenHeight)}
+	in.MouseWorld = Vector2{X: random.Float32()*400 - 200, Y: random.Float32()*300 - 150}
+	in.MouseLeftDown = random.Intn(2) == 0
+	in.MouseLeftPressed = random.Intn(8) == 0
+	in.MouseLeftReleased = random.Intn(8) == 0
+	return in
+}
+
+// testWorld is a world without spawners holding the player at the origin.
+func testWorld() (*World, *Entity) {
+	var config Config = DefaultConfig()
+	config.Spawners = nil
+	var world *World = NewWorld(config)
+	playerEntity, _ := world.Get(world.Player)
+	playerEntity.Position = Vector2{}
+	return world, playerEntity
+}
+
+// spawnAt spawns an archetype or fails the test.
+func spawnAt(t *testing.T, world *World, archetype string, position Vector2) *Entity {
+	entity, err := world.spawnArchetype(archetype, position)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entity
+}
+
+// dummyAt spawns a goblin that stands still and takes a beating, a target for
+// attacks.
+func dummyAt(t *testing.T, world *World, position Vector2) *Entity {
+	var goblin *Entity = spawnAt(t, world, "goblin", position)
+	goblin.AI = AIConfig{}
+	goblin.Health = 1000
+	return goblin
+}
+
+// stepEvents runs headless ticks of in and hands every event to each.
+func stepEvents(world *World, ticks int, in InputState, each func(event Event)) {
+	for i := 0; i < ticks; i++ {
+		world.Step(world.TickDuration(), in)
+		for _, event := range world.Events {
+			each(event)
+		}
+		world.Events = world.Events[:0]
+	}
+}
@@ -0,0 +1,36 @@
+package game
+
+import "math"
+
+// :impact
+// An attack with a Width detonates instead of hitting only what it touches:
+// everything it can hit within Width of the blast takes Damage once, less
+// toward the edge by Falloff (0 the same everywhere, 1 nothing at the edge).
+// Whether it goes off on its first hit, at the end of its range or both comes
+// from its archetype.
+
+// reachedMaxRange is true once a projectile is at or past MaxPosition, a fast
+// one may step over it without ever being close.
+func reachedMaxRange(en *Entity) bool {
+	var toMax Vector2 = Vector2Subtract(en.MaxPosition, en.Position)
+	return Vector2Length(toMax) < 5 || Vector2DotProduct(toMax, en.inputAxis) <= 0
+}
+
+func blastDamage(attack *Entity, distance float32) int32 {
+	var radius float32 = float32(attack.Width)
+	var scale float32 = 1
+	if radius > 0 {
+		scale = 1 - attack.Falloff*min(distance/radius, 1)
+	}
+	return max(1, int32(math.Round(float64(float32(attack.Damage)*scale))))
+}
+
+// detonate blasts attack at center. It uses world.queryBuffer, callers must
+// be done with it.
+func (world *World) detonate(attack *Entity, center Vector2) {
+	var radius float32 = float32(attack.Width)
+	world.forTargetsInRadius(attack.Faction, attack.Owner, center, radius, func(en *Entity) {
+		world.applyDamage(attack, en, blastDamage(attack, Vector2Distance(center, en.Position)), attack.DamageType)
+	})
+	world.emit(Event{Type: EVENT_EXPLOSION, Entity: attack.Handle, Position: center, Radius: radius})
+}
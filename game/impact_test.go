@@ -0,0 +1,83 @@
+package game
+
+import "testing"
+
+func TestBlastDamageFallsOff(t *testing.T) {
+	var tests = []struct {
+		damage   int32
+		width    int32
+		falloff  float32
+		distance float32
+		expected int32
+	}{
+		{4, 16, 0.5, 0, 4},
+		{4, 16, 0.5, 8, 3},
+		{4, 16, 0.5, 16, 2},
+		// past the edge stays at the edge damage
+		{4, 16, 0.5, 40, 2},
+		{4, 16, 0, 16, 4},
+		// a blast always does something to what it reaches
+		{4, 16, 1, 16, 1},
+	}
+	for _, test := range tests {
+		var attack Entity = Entity{Damage: test.damage, Width: test.width, Falloff: test.falloff}
+		if damage := blastDamage(&attack, test.distance); damage != test.expected {
+			t.Errorf("damage %d width %d falloff %v at %v did %d, expected %d", test.damage, test.width, test.falloff, test.distance, damage, test.expected)
+		}
+	}
+}
+
+// fireball casts the fireball effect from the player toward target.
+func fireball(world *World, playerEntity *Entity, target Vector2) {
+	world.runEffects([]Effect{{Kind: EFFECT_PROJECTILE, Archetype: "attack_fireball"}}, playerEntity, target, &Entity{})
+}
+
+func TestBlastHitsEveryTargetOnce(t *testing.T) {
+	world, playerEntity := testWorld()
+	// overlapping goblins, the fireball touches both on the same tick
+	var goblins []*Entity = []*Entity{
+		dummyAt(t, world, Vector2{X: 50}),
+		dummyAt(t, world, Vector2{X: 52, Y: 4}),
+		dummyAt(t, world, Vector2{X: 60, Y: -6}),
+	}
+	fireball(world, playerEntity, Vector2{X: 100})
+
+	var explosions int = 0
+	stepEvents(world, 60, InputState{}, func(event Event) {
+		if event.Type == EVENT_EXPLOSION {
+			explosions += 1
+		}
+	})
+	if explosions != 1 {
+		t.Fatalf("the fireball exploded %d times", explosions)
+	}
+	for i, goblin := range goblins {
+		var taken int32 = 1000 - goblin.Health
+		if taken < 1 || taken > 4 {
+			t.Fatalf("goblin %d took %d damage, one blast of the fireball does 1 to 4", i, taken)
+		}
+	}
+}
+
+func TestBlastAtMaxRange(t *testing.T) {
+	world, playerEntity := testWorld()
+	// off the line of flight, only the blast at the end of the range reaches
+	var goblin *Entity = dummyAt(t, world, Vector2{X: 100, Y: 22})
+	fireball(world, playerEntity, Vector2{X: 100})
+
+	var blasts []Vector2 = nil
+	stepEvents(world, 60, InputState{}, func(event Event) {
+		if event.Type == EVENT_EXPLOSION {
+			blasts = append(blasts, event.Position)
+		}
+	})
+	if len(blasts) != 1 || blasts[0].X < 95 {
+		t.Fatalf("expected one blast at the end of the range, got %v", blasts)
+	}
+	if goblin.Health == 1000 {
+		t.Fatal("the blast at the end of the range missed the goblin")
+	}
+	if goblin.Health < 996 {
+		t.Fatalf("the goblin took %d damage from one blast of 4", 1000-goblin.Health)
+	}
+}
@@ -0,0 +1,17 @@
+package game
+
+// InputState is everything the simulation reads from the player in one step.
+// main fills it from raylib, tests and headless runs build it by hand.
+type InputState struct {
+	// movement axis, each component in [-1, 1]
+	Axis    Vector2
+	Running bool
+
+	MouseScreen Vector2
+	MouseWorld  Vector2
+
+	MouseLeftDown     bool
+	MouseLeftPressed  bool
+	MouseLeftReleased bool
+	MouseRightPressed bool
+}
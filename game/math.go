@@ -0,0 +1,93 @@
+package game
+
+import "math"
+
+// Vector2 and Rectangle have the same layout as their raylib counterparts so
+// the renderer can convert them with rl.Vector2(v) / rl.Rectangle(r) while the
+// simulation stays free of cgo and can run without a window.
+type Vector2 struct {
+	X float32
+	Y float32
+}
+
+type Rectangle struct {
+	X      float32
+	Y      float32
+	Width  float32
+	Height float32
+}
+
+// :math vector2
+func Vector2Add(v1, v2 Vector2) Vector2 {
+	return Vector2{X: v1.X + v2.X, Y: v1.Y + v2.Y}
+}
+
+func Vector2AddValue(v Vector2, add float32) Vector2 {
+	return Vector2{X: v.X + add, Y: v.Y + add}
+}
+
+func Vector2Subtract(v1, v2 Vector2) Vector2 {
+	return Vector2{X: v1.X - v2.X, Y: v1.Y - v2.Y}
+}
+
+func Vector2Scale(v Vector2, scale float32) Vector2 {
+	return Vector2{X: v.X * scale, Y: v.Y * scale}
+}
+
+func Vector2Length(v Vector2) float32 {
+	return float32(math.Sqrt(float64(v.X*v.X + v.Y*v.Y)))
+}
+
+func Vector2Normalize(v Vector2) Vector2 {
+	if l := Vector2Length(v); l > 0 {
+		return Vector2Scale(v, 1/l)
+	}
+	return v
+}
+
+func Vector2DotProduct(v1, v2 Vector2) float32 {
+	return v1.X*v2.X + v1.Y*v2.Y
+}
+
+func Vector2Distance(v1, v2 Vector2) float32 {
+	return float32(math.Sqrt(float64((v1.X-v2.X)*(v1.X-v2.X) + (v1.Y-v2.Y)*(v1.Y-v2.Y))))
+}
+
+func Vector2Lerp(v1, v2 Vector2, amount float32) Vector2 {
+	return Vector2{X: v1.X + amount*(v2.X-v1.X), Y: v1.Y + amount*(v2.Y-v1.Y)}
+}
+
+// :math collision
+func CheckCollisionRecs(rec1, rec2 Rectangle) bool {
+	return rec1.X < (rec2.X+rec2.Width) && (rec1.X+rec1.Width) > rec2.X &&
+		rec1.Y < (rec2.Y+rec2.Height) && (rec1.Y+rec1.Height) > rec2.Y
+}
+
+func CheckCollisionPointRec(point Vector2, rec Rectangle) bool {
+	return point.X >= rec.X && point.X < (rec.X+rec.Width) && point.Y >= rec.Y && point.Y < (rec.Y+rec.Height)
+}
+
+func CheckCollisionCircleRec(center Vector2, radius float32, rec Rectangle) bool {
+	var recCenterX float32 = rec.X + rec.Width/2.0
+	var recCenterY float32 = rec.Y + rec.Height/2.0
+
+	var dx float32 = float32(math.Abs(float64(center.X - recCenterX)))
+	var dy float32 = float32(math.Abs(float64(center.Y - recCenterY)))
+
+	if dx > (rec.Width/2.0 + radius) {
+		return false
+	}
+	if dy > (rec.Height/2.0 + radius) {
+		return false
+	}
+
+	if dx <= (rec.Width / 2.0) {
+		return true
+	}
+	if dy <= (rec.Height / 2.0) {
+		return true
+	}
+
+	var cornerDistanceSq float32 = (dx-rec.Width/2.0)*(dx-rec.Width/2.0) + (dy-rec.Height/2.0)*(dy-rec.Height/2.0)
+	return cornerDistanceSq <= (radius * radius)
+}
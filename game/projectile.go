@@ -0,0 +1,185 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// :projectile behaviors
+// Besides flying straight at Speed and dying on its first hit a projectile may
+// pierce, bounce, home in, split and speed up or slow down. Every part of its
+// behavior is optional and they all apply together, a homing fireball that
+// splits has a turn_rate and a split in the same behavior. The behavior comes
+// from the attack's archetype, a card's projectile effect may bring its own
+// instead.
+
+type ProjectileBehavior struct {
+	// targets it passes through, the hit after that stops it
+	Pierce int32 `json:"pierce"`
+	// hits that send it on toward the nearest target within bounce_range it
+	// hasn't hit yet, every bounce flies its full range again
+	Bounce      int32   `json:"bounce"`
+	BounceRange float32 `json:"bounce_range"`
+	// degrees per second it turns toward the nearest target within
+	// homing_radius
+	TurnRate     float32 `json:"turn_rate"`
+	HomingRadius float32 `json:"homing_radius"`
+	// every hit fans split_count projectiles of the split archetype out over
+	// split_angle degrees around its heading, fragments don't split again
+	Split      string  `json:"split"`
+	SplitCount int32   `json:"split_count"`
+	SplitAngle float32 `json:"split_angle"`
+	// units per second squared, negative slows it down and one that comes
+	// to a stop has reached its range. max_speed caps it, 0 for no cap
+	Acceleration float32 `json:"acceleration"`
+	MaxSpeed     float32 `json:"max_speed"`
+}
+
+func (behavior *ProjectileBehavior) validate() error {
+	if behavior.Pierce < 0 || behavior.Bounce < 0 || behavior.BounceRange < 0 || behavior.TurnRate < 0 || behavior.HomingRadius < 0 {
+		return errors.New("pierce, bounce, bounce_range, turn_rate and homing_radius can't be negative")
+	}
+	if behavior.SplitCount < 0 || behavior.SplitAngle < 0 || behavior.MaxSpeed < 0 {
+		return errors.New("split_count, split_angle and max_speed can't be negative")
+	}
+	if int(behavior.Pierce+behavior.Bounce) >= MAX_HIT_TARGETS {
+		return fmt.Errorf("pierce and bounce add up to at most %d, a projectile remembers %d targets", MAX_HIT_TARGETS-1, MAX_HIT_TARGETS)
+	}
+	if behavior.Bounce > 0 && behavior.BounceRange == 0 {
+		return errors.New("bounce needs a bounce_range to find the next target in")
+	}
+	if behavior.TurnRate > 0 && behavior.HomingRadius == 0 {
+		return errors.New("homing needs a homing_radius to find a target in")
+	}
+	if (behavior.Split == "") != (behavior.SplitCount == 0) {
+		return errors.New("split needs both an archetype and a split_count")
+	}
+	return nil
+}
+
+// validateSplit checks the fragments are projectiles that don't split again,
+// it needs every archetype so it runs once they are all parsed.
+func (behavior *ProjectileBehavior) validateSplit(archetypes map[string]Archetype) error {
+	if behavior.Split == "" {
+		return nil
+	}
+	fragment, ok := archetypes[behavior.Split]
+	if !ok {
+		return fmt.Errorf("unknown split archetype %q", behavior.Split)
+	}
+	if fragment.ArchType != ARCH_ATTACK || !fragment.Projectile {
+		return fmt.Errorf("split archetype %q is not a projectile attack", behavior.Split)
+	}
+	if fragment.Behavior.Split != "" {
+		return fmt.Errorf("split archetype %q splits again", behavior.Split)
+	}
+	return nil
+}
+
+// aimProjectile sends attack along axis with distance left to fly.
+func aimProjectile(attack *Entity, axis Vector2, distance float32) {
+	attack.inputAxis = axis
+	attack.MaxPosition = Vector2Add(attack.Position, Vector2Scale(axis, distance))
+}
+
+// turnToward turns axis toward direction by at most maxTurn radians.
+func turnToward(axis Vector2, direction Vector2, maxTurn float64) Vector2 {
+	var heading float64 = math.Atan2(float64(axis.Y), float64(axis.X))
+	var turn float64 = math.Remainder(math.Atan2(float64(direction.Y), float64(direction.X))-heading, 2*math.Pi)
+	heading += math.Max(-maxTurn, math.Min(turn, maxTurn))
+	return Vector2{X: float32(math.Cos(heading)), Y: float32(math.Sin(heading))}
+}
+
+// nearestTarget is the closest entity within radius of center that attack
+// can hit and hasn't hit yet. It uses world.queryBuffer.
+func (world *World) nearestTarget(attack *Entity, center Vector2, radius float32) (*Entity, bool) {
+	var nearest *Entity = nil
+	var nearestDistance float32 = math.MaxFloat32
+	world.queryBuffer = world.QueryCircle(center, radius, world.queryBuffer[:0])
+	for _, handle := range world.queryBuffer {
+		en, ok := world.Get(handle)
+		if !ok || !world.canHit(attack, en) || hasHit(attack, handle) {
+			continue
+		}
+		if distance := Vector2Distance(center, en.Position); distance < nearestDistance {
+			nearest = en
+			nearestDistance = distance
+		}
+	}
+	return nearest, nearest != nil
+}
+
+// updateProjectile speeds up or slows down a projectile, turns it toward what
+// it homes in on and moves it.
+func (world *World) updateProjectile(attack *Entity, delta_t float32) {
+	var behavior *ProjectileBehavior = &attack.Behavior
+	if behavior.Acceleration != 0 {
+		attack.ProjectileSpeed = max(attack.ProjectileSpeed+behavior.Acceleration*delta_t, 0)
+		if behavior.MaxSpeed > 0 {
+			attack.ProjectileSpeed = min(attack.ProjectileSpeed, behavior.MaxSpeed)
+		}
+		if attack.ProjectileSpeed == 0 {
+			// stopped short, :update takes it for at its range
+			attack.MaxPosition = attack.Position
+			return
+		}
+	}
+	if behavior.TurnRate > 0 {
+		if target, ok := world.nearestTarget(attack, attack.Position, behavior.HomingRadius); ok {
+			var maxTurn float64 = float64(behavior.TurnRate*delta_t) * math.Pi / 180
+			var axis Vector2 = turnToward(attack.inputAxis, Vector2Subtract(target.Position, attack.Position), maxTurn)
+			aimProjectile(attack, axis, Vector2Distance(attack.Position, attack.MaxPosition))
+		}
+	}
+	attack.Position = Vector2Add(attack.Position, Vector2Scale(attack.inputAxis, attack.ProjectileSpeed*delta_t))
+}
+
+// projectileHit runs the behavior of attack for its hit on target, the damage
+// is already dealt. The projectile is destroyed once its behavior doesn't
+// carry it on. It uses world.queryBuffer.
+func (world *World) projectileHit(attack *Entity, target *Entity) {
+	var behavior *ProjectileBehavior = &attack.Behavior
+	if attack.detonateOnImpact {
+		world.detonate(attack, attack.Position)
+	}
+	if behavior.Split != "" {
+		world.splitProjectile(attack)
+	}
+
+	if behavior.Pierce > 0 {
+		behavior.Pierce -= 1
+		return
+	}
+	if behavior.Bounce > 0 {
+		behavior.Bounce -= 1
+		if next, ok := world.nearestTarget(attack, target.Position, behavior.BounceRange); ok {
+			aimProjectile(attack, Vector2Normalize(Vector2Subtract(next.Position, attack.Position)), float32(attack.Range))
+			return
+		}
+	}
+	world.destroyEntity(attack)
+}
+
+// splitProjectile fans the fragments of attack out around its heading, they
+// fly for its owner, carry its status and skip what it already hit.
+func (world *World) splitProjectile(attack *Entity) {
+	var behavior *ProjectileBehavior = &attack.Behavior
+	var heading float64 = math.Atan2(float64(attack.inputAxis.Y), float64(attack.inputAxis.X))
+	var spread float64 = float64(behavior.SplitAngle) * math.Pi / 180
+	for i := int32(0); i < behavior.SplitCount; i++ {
+		var angle float64 = heading
+		if behavior.SplitCount > 1 {
+			angle = heading - spread/2 + spread*float64(i)/float64(behavior.SplitCount-1)
+		}
+		var target Vector2 = Vector2Add(attack.Position, Vector2{X: float32(math.Cos(angle)), Y: float32(math.Sin(angle))})
+		fragment, err := world.spawnAttack(behavior.Split, attack, target)
+		if err != nil {
+			return
+		}
+		fragment.Owner = attack.Owner
+		fragment.Inflicts = attack.Inflicts
+		fragment.InflictDuration = attack.InflictDuration
+		fragment.HitTargets = attack.HitTargets
+	}
+}
@@ -0,0 +1,123 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+// targetWorld is a test world with dummies at targets.
+func targetWorld(t *testing.T, targets ...Vector2) (*World, []*Entity) {
+	world, _ := testWorld()
+	var goblins []*Entity = nil
+	for _, position := range targets {
+		goblins = append(goblins, dummyAt(t, world, position))
+	}
+	return world, goblins
+}
+
+// fire shoots an attack_basic of the player toward target with behavior.
+func fire(t *testing.T, world *World, target Vector2, behavior ProjectileBehavior) *Entity {
+	playerEntity, _ := world.Get(world.Player)
+	attack, err := world.spawnAttack("attack_basic", playerEntity, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attack.Behavior = behavior
+	return attack
+}
+
+// stepHits runs headless ticks and counts the hits every entity took.
+func stepHits(world *World, ticks int) map[EntityHandle]int {
+	var hits map[EntityHandle]int = make(map[EntityHandle]int)
+	stepEvents(world, ticks, InputState{}, func(event Event) {
+		if event.Type == EVENT_DAMAGE {
+			hits[event.Damage.Target] += 1
+		}
+	})
+	return hits
+}
+
+func TestProjectilePierceRunsOut(t *testing.T) {
+	world, goblins := targetWorld(t, Vector2{X: 20}, Vector2{X: 35}, Vector2{X: 50}, Vector2{X: 65})
+	var attack *Entity = fire(t, world, Vector2{X: 100}, ProjectileBehavior{Pierce: 2})
+	var handle EntityHandle = attack.Handle
+
+	var hits map[EntityHandle]int = stepHits(world, 60)
+	for i := 0; i < 3; i++ {
+		if hits[goblins[i].Handle] != 1 {
+			t.Fatalf("goblin %d was hit %d times, a pierce 2 projectile hits the first 3 once", i, hits[goblins[i].Handle])
+		}
+	}
+	if hits[goblins[3].Handle] != 0 {
+		t.Fatal("the projectile went on after its pierces ran out")
+	}
+	if _, ok := world.Get(handle); ok {
+		t.Fatal("the projectile outlived its pierces")
+	}
+}
+
+func TestProjectileBouncesToTheNearestTarget(t *testing.T) {
+	// the second goblin is off the line of flight, only a bounce reaches it,
+	// the third is past the bounce range
+	world, goblins := targetWorld(t, Vector2{X: 30}, Vector2{X: 30, Y: 50}, Vector2{X: 30, Y: -200})
+	fire(t, world, Vector2{X: 100}, ProjectileBehavior{Bounce: 2, BounceRange: 80})
+
+	var hits map[EntityHandle]int = stepHits(world, 90)
+	if hits[goblins[0].Handle] != 1 || hits[goblins[1].Handle] != 1 {
+		t.Fatalf("expected the projectile to hit the first goblin and bounce into the second, hits %v", hits)
+	}
+	if hits[goblins[2].Handle] != 0 {
+		t.Fatal("the projectile bounced to a goblin out of its bounce range")
+	}
+}
+
+func TestProjectileSplitsIntoFragments(t *testing.T) {
+	world, _ := targetWorld(t, Vector2{X: 30})
+	var attack *Entity = fire(t, world, Vector2{X: 100}, ProjectileBehavior{Split: "attack_ember", SplitCount: 3, SplitAngle: 90})
+	var owner EntityHandle = attack.Owner
+
+	for i := 0; i < 60 && attack.IsValid; i++ {
+		world.Step(world.TickDuration(), InputState{})
+	}
+	var fragments int = 0
+	for i := 0; i < MAX_ENTITY_COUNT; i++ {
+		var entity *Entity = &world.Entities[i]
+		if entity.IsValid && entity.Archetype == "attack_ember" {
+			if entity.Owner != owner {
+				t.Fatal("a fragment doesn't fly for the owner of the projectile")
+			}
+			fragments += 1
+		}
+	}
+	if fragments != 3 {
+		t.Fatalf("the projectile split into %d fragments, expected 3", fragments)
+	}
+}
+
+func TestProjectileHomesInOnTarget(t *testing.T) {
+	// aimed straight right, the goblin is well off that line
+	var target Vector2 = Vector2{X: 50, Y: 40}
+	world, goblins := targetWorld(t, target)
+	var attack *Entity = fire(t, world, Vector2{X: 100}, ProjectileBehavior{TurnRate: 360, HomingRadius: 120})
+
+	world.Step(world.TickDuration(), InputState{})
+	world.Step(world.TickDuration(), InputState{})
+	var heading float64 = math.Atan2(float64(attack.inputAxis.Y), float64(attack.inputAxis.X))
+	if heading <= 0 {
+		t.Fatalf("the projectile didn't turn toward the goblin, heading %v", attack.inputAxis)
+	}
+	if maxTurn := 2 * world.TickDuration() * 360 * math.Pi / 180; heading > float64(maxTurn)+1e-4 {
+		t.Fatalf("the projectile turned %v radians in 2 ticks, faster than its turn rate allows", heading)
+	}
+
+	if hits := stepHits(world, 60); hits[goblins[0].Handle] != 1 {
+		t.Fatalf("the homing projectile hit the goblin %d times", hits[goblins[0].Handle])
+	}
+
+	// the same shot without homing flies past
+	world, goblins = targetWorld(t, target)
+	fire(t, world, Vector2{X: 100}, ProjectileBehavior{})
+	if hits := stepHits(world, 60); hits[goblins[0].Handle] != 0 {
+		t.Fatal("the goblin is in the way of the straight shot, the test proves nothing")
+	}
+}
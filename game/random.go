@@ -0,0 +1,48 @@
+package game
+
+// Random is the game owned PRNG (splitmix64). Everything in the simulation that
+// needs randomness draws from World.Random so a run is reproduced exactly by
+// its seed and inputs, and the whole generator state is one number that can be
+// saved.
+type Random struct {
+	State uint64
+}
+
+func NewRandom(seed uint64) Random {
+	return Random{State: seed}
+}
+
+func (random *Random) Uint64() uint64 {
+	random.State += 0x9e3779b97f4a7c15
+	var z uint64 = random.State
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Float32 returns a value in [0, 1).
+func (random *Random) Float32() float32 {
+	return float32(random.Uint64()>>40) / float32(1<<24)
+}
+
+// Int32Range returns a value in [min, max], both inclusive like
+// rl.GetRandomValue.
+func (random *Random) Int32Range(min, max int32) int32 {
+	if max <= min {
+		return min
+	}
+	var span uint64 = uint64(int64(max) - int64(min) + 1)
+	return min + int32(random.Uint64()%span)
+}
+
+func (random *Random) Float32Range(min, max float32) float32 {
+	return min + (max-min)*random.Float32()
+}
+
+// Intn returns a value in [0, n), used for shuffles.
+func (random *Random) Intn(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return int(random.Uint64() % uint64(n))
+}
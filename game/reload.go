@@ -0,0 +1,171 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// :hot reload
+// Data files are watched by polling their modification time, there are only a
+// handful and an os.Stat each is far cheaper than a frame.
+
+type watchedFile struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+type DataWatcher struct {
+	files []watchedFile
+}
+
+// NewDataWatcher starts watching paths as they are now, only later changes are
+// reported. A path that doesn't exist yet is reported once it does.
+func NewDataWatcher(paths ...string) *DataWatcher {
+	var watcher *DataWatcher = &DataWatcher{}
+	for _, path := range paths {
+		var file watchedFile = watchedFile{path: path}
+		if info, err := os.Stat(path); err == nil {
+			file.modTime = info.ModTime()
+			file.size = info.Size()
+		}
+		watcher.files = append(watcher.files, file)
+	}
+	return watcher
+}
+
+// Changed returns the paths written since the last call.
+func (watcher *DataWatcher) Changed() []string {
+	var changed []string
+	for i := 0; i < len(watcher.files); i++ {
+		var file *watchedFile = &watcher.files[i]
+		info, err := os.Stat(file.path)
+		if err != nil {
+			// mid save some editors remove the file before writing it again
+			continue
+		}
+		if info.ModTime().Equal(file.modTime) && info.Size() == file.size {
+			continue
+		}
+		file.modTime = info.ModTime()
+		file.size = info.Size()
+		changed = append(changed, file.path)
+	}
+	return changed
+}
+
+// ReloadArchetypes swaps in new archetype definitions. Entities spawned from
+// now on use them, and with live set the entities already in the world take
+// the changed stats too. Health moves by the change in max health so damage
+// already taken stays taken. Attacks already in flight keep what they were
+// spawned with. The reload is refused if a spawner or card names an archetype
+// that no longer exists. Returns how many live entities changed.
+func (world *World) ReloadArchetypes(archetypes map[string]Archetype, live bool) (int, error) {
+	for i := 0; i < len(world.Config.Spawners); i++ {
+		if err := world.Config.Spawners[i].validate(archetypes); err != nil {
+			return 0, fmt.Errorf("spawner %d: %w", i+1, err)
+		}
+	}
+	for name, card := range world.Config.Cards {
+		if err := card.validate(archetypes, world.Config.Statuses); err != nil {
+			return 0, fmt.Errorf("card %q: %w", name, err)
+		}
+	}
+
+	var previous map[string]Archetype = world.Config.Archetypes
+	// a new map, a save or replay holding the old one keeps it as it was
+	world.Config.Archetypes = archetypes
+	if !live {
+		return 0, nil
+	}
+
+	var updated int = 0
+	reapply := func(en *Entity) {
+		// an attack carries its card's overrides and the pierces and
+		// bounces it used up, the archetype would wipe them
+		if !en.IsValid || en.Archetype == "" || en.Type == ARCH_ATTACK {
+			return
+		}
+		before, hadBefore := previous[en.Archetype]
+		after, ok := archetypes[en.Archetype]
+		if !ok || (hadBefore && before == after) {
+			return
+		}
+		reapplyArchetype(en, &before, &after)
+		updated += 1
+	}
+	for i := 0; i < MAX_ENTITY_COUNT; i++ {
+		reapply(&world.Entities[i])
+	}
+	for i := 0; i < MAX_HAND_COUNT; i++ {
+		reapply(&world.Hand.Cards[i])
+		if world.Hand.Cards[i].IsValid {
+			updateCardRectangle(&world.Hand.Cards[i])
+		}
+	}
+	return updated, nil
+}
+
+// reapplyArchetype moves a live entity from one version of its archetype to
+// the next. The type and faction stay, an entity can't become something else
+// or change sides mid flight.
+func reapplyArchetype(en *Entity, before *Archetype, after *Archetype) {
+	var health int32 = en.Health + after.Health - before.Health
+	if health < 1 && after.ArchType != ARCH_CARD {
+		health = 1
+	}
+
+	var archType EntityArchType = en.Type
+	var faction Faction = en.Faction
+	var mana float32 = en.Mana
+	applyArchetype(en, en.Archetype, after)
+	en.Type = archType
+	en.Faction = faction
+	en.Health = health
+	en.Mana = min(mana, float32(en.MaxMana))
+	updateCollisionRectangle(en)
+}
+
+// ReloadSpawners swaps in new spawners, spawners at the same index as before
+// keep their timer.
+func (world *World) ReloadSpawners(spawners []Spawner) {
+	world.Config.Spawners = spawners
+	world.spawnTimers = spawnTimersFor(spawners, world.spawnTimers)
+}
+
+// ReloadCards swaps in new card definitions, cards in the hand and piles play
+// by the new ones from now on. Copies only shape the starting deck, a change
+// shows with the next run. The reload is refused while a card it drops is
+// still somewhere in the cycle.
+func (world *World) ReloadCards(cards map[string]Card) error {
+	var inCycle []string = nil
+	inCycle = append(inCycle, world.Deck.DrawPile...)
+	inCycle = append(inCycle, world.Deck.DiscardPile...)
+	for i := 0; i < MAX_HAND_COUNT; i++ {
+		if world.Hand.Cards[i].IsValid {
+			inCycle = append(inCycle, world.Hand.Cards[i].Card)
+		}
+	}
+	for _, name := range inCycle {
+		if _, ok := cards[name]; !ok {
+			return fmt.Errorf("card %q is still in the deck", name)
+		}
+	}
+
+	world.Config.Cards = cards
+	return nil
+}
+
+// ReloadStatuses swaps in new status definitions, refused if a card applies a
+// status that no longer exists. Entities under a status that is gone come out
+// of it on their next tick.
+func (world *World) ReloadStatuses(statuses map[string]Status) error {
+	for name, card := range world.Config.Cards {
+		if err := card.validate(world.Config.Archetypes, statuses); err != nil {
+			return fmt.Errorf("card %q: %w", name, err)
+		}
+	}
+	world.Config.Statuses = statuses
+	return nil
+}
@@ -0,0 +1,84 @@
+package game
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestLiveReloadKeepsDamageTaken(t *testing.T) {
+	world, _ := testWorld()
+	var goblin *Entity = spawnAt(t, world, "goblin", Vector2{X: 100})
+	var untouched *Entity = spawnAt(t, world, "troll", Vector2{X: -100})
+	goblin.Health -= 2
+	var health int32 = goblin.Health
+
+	var archetypes map[string]Archetype = maps.Clone(world.Config.Archetypes)
+	var archetype Archetype = archetypes["goblin"]
+	archetype.Health += 10
+	archetype.Speed += 5
+	archetypes["goblin"] = archetype
+
+	updated, err := world.ReloadArchetypes(archetypes, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated != 1 {
+		t.Fatalf("reload changed %d entities, only the goblin changed", updated)
+	}
+	if goblin.Health != health+10 || goblin.Speed != archetype.Speed {
+		t.Fatalf("reloaded goblin has health %d and speed %d, expected %d and %d", goblin.Health, goblin.Speed, health+10, archetype.Speed)
+	}
+	if untouched.Health != archetypes["troll"].Health {
+		t.Fatal("reload touched the troll whose archetype didn't change")
+	}
+
+	// without live only what spawns from now on changes
+	archetype.Speed += 5
+	archetypes = maps.Clone(archetypes)
+	archetypes["goblin"] = archetype
+	if _, err := world.ReloadArchetypes(archetypes, false); err != nil {
+		t.Fatal(err)
+	}
+	if goblin.Speed == archetype.Speed {
+		t.Fatal("a reload without live changed a goblin already in the world")
+	}
+	if spawned := spawnAt(t, world, "goblin", Vector2{}); spawned.Speed != archetype.Speed {
+		t.Fatal("a goblin spawned after the reload doesn't use the new archetype")
+	}
+}
+
+func TestReloadRefusesArchetypesSpawnersNeed(t *testing.T) {
+	var world *World = NewWorld(DefaultConfig())
+	var archetypes map[string]Archetype = maps.Clone(world.Config.Archetypes)
+	delete(archetypes, world.Config.Spawners[0].Archetype)
+
+	if _, err := world.ReloadArchetypes(archetypes, true); err == nil {
+		t.Fatal("reloaded archetypes without one a spawner spawns")
+	}
+	if _, ok := world.Config.Archetypes[world.Config.Spawners[0].Archetype]; !ok {
+		t.Fatal("the refused archetypes were swapped in anyway")
+	}
+}
+
+func TestLiveReloadLeavesAttacksInFlight(t *testing.T) {
+	world, playerEntity := testWorld()
+	attack, err := world.spawnAttack("attack_basic", playerEntity, Vector2{X: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// what a card override and a used up pierce leave behind
+	attack.Damage = 7
+	attack.Behavior.Pierce = 1
+
+	var archetypes map[string]Archetype = maps.Clone(world.Config.Archetypes)
+	var archetype Archetype = archetypes["attack_basic"]
+	archetype.Damage += 3
+	archetypes["attack_basic"] = archetype
+
+	if _, err := world.ReloadArchetypes(archetypes, true); err != nil {
+		t.Fatal(err)
+	}
+	if attack.Damage != 7 || attack.Behavior.Pierce != 1 {
+		t.Fatalf("reload changed an attack in flight to damage %d and pierce %d", attack.Damage, attack.Behavior.Pierce)
+	}
+}
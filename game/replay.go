@@ -0,0 +1,273 @@
+package game
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+)
+
+// :replay file format
+// magic, version, length prefixed JSON Config, then one fixed size record per
+// tick until the end of the file, all little endian
+// version 2: the spawners moved into Config.Spawners
+// version 3: the deck settings in Config
+// version 4: cards play effects
+// version 5: projectiles stop at their range and can detonate
+// version 6: enemies are driven by their ai
+// version 7: enemies attack
+// version 8: melee attacks swing around their owner
+// version 9: factions decide hits
+// version 10: hits go through the damage pipeline
+// version 11: statuses in Config
+// version 12: the player dies, lives in Config
+// version 13: hits knock back and stun
+// version 14: projectiles have behaviors
+const (
+	REPLAY_MAGIC   = "DMREPLAY"
+	REPLAY_VERSION = 14
+)
+
+var ErrReplayMagic = errors.New("not a replay file")
+
+const (
+	replayButtonLeftDown uint8 = 1 << iota
+	replayButtonLeftPressed
+	replayButtonLeftReleased
+	replayButtonRightPressed
+	replayButtonRunning
+)
+
+// ReplayFrame is the input of one tick and the world checksum right after
+// that tick ran.
+type ReplayFrame struct {
+	Input    InputState
+	Checksum uint64
+}
+
+type Replay struct {
+	Config Config
+	Frames []ReplayFrame
+}
+
+type replayRecord struct {
+	AxisX        float32
+	AxisY        float32
+	MouseScreenX float32
+	MouseScreenY float32
+	MouseWorldX  float32
+	MouseWorldY  float32
+	Buttons      uint8
+	Checksum     uint64
+}
+
+func recordFromFrame(frame ReplayFrame) replayRecord {
+	var in InputState = frame.Input
+	var record replayRecord = replayRecord{
+		AxisX:        in.Axis.X,
+		AxisY:        in.Axis.Y,
+		MouseScreenX: in.MouseScreen.X,
+		MouseScreenY: in.MouseScreen.Y,
+		MouseWorldX:  in.MouseWorld.X,
+		MouseWorldY:  in.MouseWorld.Y,
+		Checksum:     frame.Checksum,
+	}
+
+	var flags = []struct {
+		set  bool
+		flag uint8
+	}{
+		{in.MouseLeftDown, replayButtonLeftDown},
+		{in.MouseLeftPressed, replayButtonLeftPressed},
+		{in.MouseLeftReleased, replayButtonLeftReleased},
+		{in.MouseRightPressed, replayButtonRightPressed},
+		{in.Running, replayButtonRunning},
+	}
+	for _, f := range flags {
+		if f.set {
+			record.Buttons |= f.flag
+		}
+	}
+	return record
+}
+
+func frameFromRecord(record replayRecord) ReplayFrame {
+	var in InputState
+	in.Axis = Vector2{X: record.AxisX, Y: record.AxisY}
+	in.MouseScreen = Vector2{X: record.MouseScreenX, Y: record.MouseScreenY}
+	in.MouseWorld = Vector2{X: record.MouseWorldX, Y: record.MouseWorldY}
+	in.MouseLeftDown = record.Buttons&replayButtonLeftDown != 0
+	in.MouseLeftPressed = record.Buttons&replayButtonLeftPressed != 0
+	in.MouseLeftReleased = record.Buttons&replayButtonLeftReleased != 0
+	in.MouseRightPressed = record.Buttons&replayButtonRightPressed != 0
+	in.Running = record.Buttons&replayButtonRunning != 0
+	return ReplayFrame{Input: in, Checksum: record.Checksum}
+}
+
+// :replay recording
+
+// ReplayRecorder streams every tick run through World.Advance to a writer.
+// Write errors are kept and reported by Close, recording stops at the first.
+type ReplayRecorder struct {
+	writer *bufio.Writer
+	err    error
+}
+
+func NewReplayRecorder(w io.Writer, config Config) (*ReplayRecorder, error) {
+	var recorder *ReplayRecorder = &ReplayRecorder{writer: bufio.NewWriter(w)}
+
+	configJson, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder.writer.WriteString(REPLAY_MAGIC)
+	binary.Write(recorder.writer, binary.LittleEndian, uint32(REPLAY_VERSION))
+	binary.Write(recorder.writer, binary.LittleEndian, uint32(len(configJson)))
+	if _, err := recorder.writer.Write(configJson); err != nil {
+		return nil, err
+	}
+	return recorder, nil
+}
+
+func (recorder *ReplayRecorder) record(frame ReplayFrame) {
+	if recorder.err != nil {
+		return
+	}
+	recorder.err = binary.Write(recorder.writer, binary.LittleEndian, recordFromFrame(frame))
+}
+
+// Close flushes what is buffered, it does not close the underlying writer.
+func (recorder *ReplayRecorder) Close() error {
+	if recorder.err != nil {
+		return recorder.err
+	}
+	return recorder.writer.Flush()
+}
+
+func LoadReplay(r io.Reader) (*Replay, error) {
+	var reader *bufio.Reader = bufio.NewReader(r)
+
+	var magic [len(REPLAY_MAGIC)]byte
+	if _, err := io.ReadFull(reader, magic[:]); err != nil || string(magic[:]) != REPLAY_MAGIC {
+		return nil, ErrReplayMagic
+	}
+
+	var version uint32
+	if err := binary.Read(reader, binary.LittleEndian, &version); err != nil {
+		return nil, err
+	}
+	if version != REPLAY_VERSION {
+		return nil, fmt.Errorf("replay version %d, this build plays version %d", version, REPLAY_VERSION)
+	}
+
+	var configLength uint32
+	if err := binary.Read(reader, binary.LittleEndian, &configLength); err != nil {
+		return nil, err
+	}
+	var configJson []byte = make([]byte, configLength)
+	if _, err := io.ReadFull(reader, configJson); err != nil {
+		return nil, err
+	}
+
+	var replay *Replay = &Replay{}
+	if err := json.Unmarshal(configJson, &replay.Config); err != nil {
+		return nil, fmt.Errorf("replay config: %w", err)
+	}
+
+	for {
+		var record replayRecord
+		err := binary.Read(reader, binary.LittleEndian, &record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// a recording cut short by a crash still plays up to the last
+			// complete tick
+			if err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, err
+		}
+		replay.Frames = append(replay.Frames, frameFromRecord(record))
+	}
+	return replay, nil
+}
+
+// :replay playback
+
+// ReplayPlayer feeds recorded input to World.Advance instead of the live
+// input and compares the world checksum after every tick with the recorded
+// one.
+type ReplayPlayer struct {
+	Replay *Replay
+	Next   int
+
+	// tick of the first checksum mismatch, 0 while the playback matches
+	DivergedTick uint64
+}
+
+func NewReplayPlayer(replay *Replay) *ReplayPlayer {
+	return &ReplayPlayer{Replay: replay}
+}
+
+func (player *ReplayPlayer) Finished() bool {
+	return player.Next >= len(player.Replay.Frames)
+}
+
+func (player *ReplayPlayer) next() (ReplayFrame, bool) {
+	if player.Finished() {
+		return ReplayFrame{}, false
+	}
+	var frame ReplayFrame = player.Replay.Frames[player.Next]
+	player.Next += 1
+	return frame, true
+}
+
+func (player *ReplayPlayer) verify(frame ReplayFrame, tick uint64, checksum uint64) {
+	if player.DivergedTick == 0 && frame.Checksum != checksum {
+		player.DivergedTick = tick
+	}
+}
+
+// :replay checksum
+
+// Checksum hashes the state that decides how the run plays out. It is cheap
+// enough to run every tick and any divergence shows up in it within a few
+// ticks.
+func (world *World) Checksum() uint64 {
+	var buffer []byte = world.checksumBuffer[:0]
+	buffer = binary.LittleEndian.AppendUint64(buffer, world.Tick)
+	buffer = binary.LittleEndian.AppendUint64(buffer, world.Random.State)
+	for i := 0; i < len(world.spawnTimers); i++ {
+		buffer = binary.LittleEndian.AppendUint32(buffer, math.Float32bits(world.spawnTimers[i]))
+	}
+
+	buffer = binary.LittleEndian.AppendUint32(buffer, uint32(len(world.Deck.DrawPile)))
+	buffer = binary.LittleEndian.AppendUint32(buffer, uint32(len(world.Deck.DiscardPile)))
+	buffer = binary.LittleEndian.AppendUint32(buffer, uint32(world.Hand.Stats().Live))
+	buffer = binary.LittleEndian.AppendUint32(buffer, uint32(world.Lives))
+	buffer = binary.LittleEndian.AppendUint32(buffer, math.Float32bits(world.DeathTimer))
+
+	for i := 0; i < MAX_ENTITY_COUNT; i++ {
+		var entity *Entity = &world.Entities[i]
+		if entity.IsValid {
+			buffer = binary.LittleEndian.AppendUint32(buffer, uint32(entity.Handle.Index))
+			buffer = binary.LittleEndian.AppendUint32(buffer, entity.Handle.Generation)
+			buffer = binary.LittleEndian.AppendUint32(buffer, uint32(entity.Type))
+			buffer = binary.LittleEndian.AppendUint32(buffer, uint32(entity.Health))
+			buffer = binary.LittleEndian.AppendUint32(buffer, math.Float32bits(entity.Position.X))
+			buffer = binary.LittleEndian.AppendUint32(buffer, math.Float32bits(entity.Position.Y))
+		}
+	}
+
+	world.checksumBuffer = buffer
+
+	var hash = fnv.New64a()
+	hash.Write(buffer)
+	return hash.Sum64()
+}
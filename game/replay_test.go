@@ -0,0 +1,68 @@
+package game
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// recordRun plays ticks of random input into a replay.
+func recordRun(t *testing.T, seed uint64, ticks int) *Replay {
+	var config Config = DefaultConfig()
+	config.Seed = seed
+	var world *World = NewWorld(config)
+	var random *rand.Rand = rand.New(rand.NewSource(int64(seed)))
+
+	var file bytes.Buffer
+	recorder, err := NewReplayRecorder(&file, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	world.Recorder = recorder
+	for i := 0; i < ticks; i++ {
+		world.Advance(world.TickDuration(), randomInput(world, random))
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := LoadReplay(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replay.Frames) != ticks {
+		t.Fatalf("recorded %d ticks, the replay has %d", ticks, len(replay.Frames))
+	}
+	return replay
+}
+
+// playBack runs a replay to its end and returns the tick it diverged at, 0
+// when it didn't.
+func playBack(replay *Replay) uint64 {
+	var world *World = NewWorld(replay.Config)
+	world.Playback = NewReplayPlayer(replay)
+	for !world.Playback.Finished() {
+		world.Advance(world.TickDuration(), InputState{})
+	}
+	return world.Playback.DivergedTick
+}
+
+func TestReplayPlaysBackWithoutDivergence(t *testing.T) {
+	var replay *Replay = recordRun(t, 6, 600)
+	if tick := playBack(replay); tick != 0 {
+		t.Fatalf("playback diverged at tick %d", tick)
+	}
+}
+
+func TestReplayReportsChangedInput(t *testing.T) {
+	var replay *Replay = recordRun(t, 7, 600)
+	// the frame of tick 101, the player walks the other way
+	var frame *ReplayFrame = &replay.Frames[100]
+	frame.Input.Axis = Vector2{X: -frame.Input.Axis.X, Y: -frame.Input.Axis.Y}
+	if frame.Input.Axis == (Vector2{}) {
+		frame.Input.Axis = Vector2{X: 1}
+	}
+	if tick := playBack(replay); tick != 101 {
+		t.Fatalf("changed the input of tick 101, playback diverged at tick %d", tick)
+	}
+}
@@ -0,0 +1,668 @@
+package game
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// :save format
+// The same SaveData is written either as indented JSON, to read and diff while
+// debugging, or as a magic header followed by gob, compact for shipping.
+// ReadSave tells them apart by the first bytes.
+const (
+	SAVE_MAGIC   = "DMSAVE"
+	SAVE_VERSION = 14
+)
+
+// :enum SaveFormat
+type SaveFormat int
+
+const (
+	SAVE_FORMAT_BINARY SaveFormat = 0
+	SAVE_FORMAT_JSON   SaveFormat = 1
+)
+
+var ErrSaveFormat = errors.New("not a save file")
+
+// saveMigrations[n] upgrades a version n save to version n+1 in place. A save
+// older than the first migration or newer than SAVE_VERSION is refused.
+var saveMigrations = map[int]func(data *SaveData) error{
+	// the goblin and troll spawners became data, version 1 configs have none.
+	// Entities of saves from before the archetypes were data don't name
+	// theirs, they are named by type so the later migrations find them
+	1: func(data *SaveData) error {
+		if data.Config.Archetypes == nil {
+			data.Config.Archetypes = maps.Clone(defaultArchetypes)
+		}
+		var archetypeOfType map[EntityArchType]string = map[EntityArchType]string{
+			ARCH_PLAYER: "player",
+			ARCH_TROLL:  "troll",
+			ARCH_GOBLIN: "goblin",
+			ARCH_CARD:   "card_fireball",
+			ARCH_ATTACK: "attack_fireball",
+		}
+		for i := 0; i < len(data.Entities); i++ {
+			var saved *savedEntity = &data.Entities[i]
+			if saved.Archetype == "" {
+				saved.Archetype = archetypeOfType[saved.Type]
+			}
+		}
+		data.Config.Spawners = slices.Clone(defaultSpawners)
+		data.SpawnTimers = make([]float32, len(data.Config.Spawners))
+		for i, spawner := range data.Config.Spawners {
+			switch spawner.Archetype {
+			case "goblin":
+				data.SpawnTimers[i] = data.ElapsedTimeGoblin
+			case "troll":
+				data.SpawnTimers[i] = data.ElapsedTimeTroll
+			}
+		}
+		return nil
+	},
+	// the lone fireball card moved out of the world into the hand, a version 2
+	// save starts the card cycle over with a fresh deck
+	2: func(data *SaveData) error {
+		var defaults Config = DefaultConfig()
+		data.Config.Cards = defaults.Cards
+		data.Config.HandSize = defaults.HandSize
+		data.Config.StartingHand = defaults.StartingHand
+		data.Config.DrawInterval = defaults.DrawInterval
+		data.Config.DrawOnKill = defaults.DrawOnKill
+
+		var entities []savedEntity = nil
+		for _, saved := range data.Entities {
+			if saved.Type == ARCH_CARD {
+				data.EntityAllocator.Free = append(data.EntityAllocator.Free, saved.Handle.Index)
+				data.EntityAllocator.Stats.Live -= 1
+				continue
+			}
+			entities = append(entities, saved)
+		}
+		data.Entities = entities
+		data.GrabbedCard = EntityHandle{}
+
+		data.Deck = Deck{DrawPile: startingDeck(data.Config.Cards)}
+		shuffleCards(&data.Random, data.Deck.DrawPile)
+		return nil
+	},
+	// cards cost mana, a version 3 save takes mana and costs from the built in
+	// definitions of the same name and its entities start with a full pool
+	3: func(data *SaveData) error {
+		for name, archetype := range data.Config.Archetypes {
+			if defaults, ok := defaultArchetypes[name]; ok {
+				archetype.Mana = defaults.Mana
+				archetype.ManaRegen = defaults.ManaRegen
+				data.Config.Archetypes[name] = archetype
+			}
+		}
+		for name, card := range data.Config.Cards {
+			if defaults, ok := defaultCards[name]; ok {
+				card.Cost = defaults.Cost
+				data.Config.Cards[name] = card
+			}
+		}
+		for i := 0; i < len(data.Entities); i++ {
+			var saved *savedEntity = &data.Entities[i]
+			if archetype, ok := data.Config.Archetypes[saved.Archetype]; ok {
+				saved.MaxMana = archetype.Mana
+				saved.ManaRegen = archetype.ManaRegen
+				saved.Mana = float32(archetype.Mana)
+			}
+		}
+		return nil
+	},
+	// cards play a list of effects instead of spawning one attack, the cards
+	// of a version 4 save take the effects of the built in card of the same
+	// name and the ones without are dropped along with their copies in the deck
+	4: func(data *SaveData) error {
+		for name, card := range data.Config.Cards {
+			if defaults, ok := defaultCards[name]; ok {
+				// a copy, later migrations edit the effects in place
+				card.Effects = slices.Clone(defaults.Effects)
+				data.Config.Cards[name] = card
+			} else {
+				delete(data.Config.Cards, name)
+			}
+		}
+		dropped := func(name string) bool {
+			_, ok := data.Config.Cards[name]
+			return !ok
+		}
+		data.Deck.DrawPile = slices.DeleteFunc(data.Deck.DrawPile, dropped)
+		data.Deck.DiscardPile = slices.DeleteFunc(data.Deck.DiscardPile, dropped)
+		data.HandCards = slices.DeleteFunc(data.HandCards, func(saved savedEntity) bool {
+			if dropped(saved.Card) {
+				data.HandAllocator.Free = append(data.HandAllocator.Free, saved.Handle.Index)
+				data.HandAllocator.Stats.Live -= 1
+				return true
+			}
+			return false
+		})
+		return nil
+	},
+	// enemies think, the archetypes of a version 5 save take the ai of the
+	// built in definition of the same name and entities learn their max health
+	5: func(data *SaveData) error {
+		for name, archetype := range data.Config.Archetypes {
+			if defaults, ok := defaultArchetypes[name]; ok {
+				archetype.AI = defaults.AI
+				data.Config.Archetypes[name] = archetype
+			}
+		}
+		for i := 0; i < len(data.Entities); i++ {
+			var saved *savedEntity = &data.Entities[i]
+			if archetype, ok := data.Config.Archetypes[saved.Archetype]; ok {
+				saved.AI = archetype.AI
+				saved.MaxHealth = max(archetype.Health, saved.Health)
+			}
+		}
+		return nil
+	},
+	// enemies attack, the ai of a version 6 save takes the attack and cooldown
+	// of the built in definition of the same name
+	6: func(data *SaveData) error {
+		for name, archetype := range data.Config.Archetypes {
+			if defaults, ok := defaultArchetypes[name]; ok {
+				archetype.AI.Attack = defaults.AI.Attack
+				archetype.AI.Cooldown = defaults.AI.Cooldown
+				data.Config.Archetypes[name] = archetype
+			}
+		}
+		for _, name := range []string{"attack_slam", "attack_sword"} {
+			if _, ok := data.Config.Archetypes[name]; !ok {
+				data.Config.Archetypes[name] = defaultArchetypes[name]
+			}
+		}
+		for i := 0; i < len(data.Entities); i++ {
+			var saved *savedEntity = &data.Entities[i]
+			if archetype, ok := data.Config.Archetypes[saved.Archetype]; ok {
+				saved.AI = archetype.AI
+			}
+		}
+		return nil
+	},
+	// hits are decided by faction, a version 7 save takes the factions of the
+	// built in archetypes and the default relations, attacks side with their
+	// owner and those whose owner is gone hit nothing
+	7: func(data *SaveData) error {
+		data.Config.Relations = defaultRelations()
+		for name, archetype := range data.Config.Archetypes {
+			if defaults, ok := defaultArchetypes[name]; ok {
+				archetype.Faction = defaults.Faction
+				data.Config.Archetypes[name] = archetype
+			}
+		}
+		var factions map[EntityHandle]Faction = make(map[EntityHandle]Faction)
+		for i := 0; i < len(data.Entities); i++ {
+			var saved *savedEntity = &data.Entities[i]
+			if archetype, ok := data.Config.Archetypes[saved.Archetype]; ok && saved.Type != ARCH_ATTACK {
+				saved.Faction = archetype.Faction
+				factions[saved.Handle] = saved.Faction
+			}
+		}
+		for i := 0; i < len(data.Entities); i++ {
+			var saved *savedEntity = &data.Entities[i]
+			if saved.Type == ARCH_ATTACK {
+				saved.Faction = factions[saved.Owner]
+			}
+		}
+		return nil
+	},
+	// hits go through the damage pipeline, a version 8 save takes damage types,
+	// armor, resistances and crits from the built in archetypes and cards of
+	// the same name
+	8: func(data *SaveData) error {
+		for name, archetype := range data.Config.Archetypes {
+			if defaults, ok := defaultArchetypes[name]; ok {
+				archetype.DamageStats = defaults.DamageStats
+				data.Config.Archetypes[name] = archetype
+			}
+		}
+		for name, card := range data.Config.Cards {
+			defaults, ok := defaultCards[name]
+			if !ok {
+				continue
+			}
+			for i := 0; i < len(card.Effects) && i < len(defaults.Effects); i++ {
+				if card.Effects[i].Kind == defaults.Effects[i].Kind {
+					card.Effects[i].DamageType = defaults.Effects[i].DamageType
+				}
+			}
+		}
+		for i := 0; i < len(data.Entities); i++ {
+			var saved *savedEntity = &data.Entities[i]
+			if archetype, ok := data.Config.Archetypes[saved.Archetype]; ok {
+				saved.DamageStats = archetype.DamageStats
+			}
+		}
+		return nil
+	},
+	// statuses became data with ticks, stacks and shields, a version 9 save
+	// takes the built in definitions and its entities keep what they are under
+	// as a single stack
+	9: func(data *SaveData) error {
+		data.Config.Statuses = maps.Clone(defaultStatuses)
+		for i := 0; i < len(data.Entities); i++ {
+			var saved *savedEntity = &data.Entities[i]
+			for j := 0; j < MAX_STATUS_COUNT; j++ {
+				var status *StatusEffect = &saved.Statuses[j]
+				if status.Name == "" {
+					continue
+				}
+				var definition Status = data.Config.Statuses[status.Name]
+				status.Stacks = 1
+				status.TickTimer = definition.TickInterval
+				status.Shield = definition.Shield
+			}
+		}
+		return nil
+	},
+	// the player can die, a version 10 save is a run on its last life with
+	// the default death animation and no stats so far
+	10: func(data *SaveData) error {
+		data.Config.DeathTime = DefaultConfig().DeathTime
+		data.Stats = RunStats{Ticks: data.Tick}
+		return nil
+	},
+	// hits push, stun and leave the player invulnerable, a version 11 save
+	// takes knockback, hit stun and invulnerability from the built in
+	// archetypes of the same name
+	11: func(data *SaveData) error {
+		data.Config.VelocityDecay = DefaultConfig().VelocityDecay
+		for name, archetype := range data.Config.Archetypes {
+			if defaults, ok := defaultArchetypes[name]; ok {
+				archetype.Knockback = defaults.Knockback
+				archetype.HitStun = defaults.HitStun
+				archetype.Invulnerability = defaults.Invulnerability
+				data.Config.Archetypes[name] = archetype
+			}
+		}
+		for i := 0; i < len(data.Entities); i++ {
+			var saved *savedEntity = &data.Entities[i]
+			if archetype, ok := data.Config.Archetypes[saved.Archetype]; ok {
+				saved.Knockback = archetype.Knockback
+				saved.HitStun = archetype.HitStun
+				saved.Invulnerability = archetype.Invulnerability
+			}
+		}
+		return nil
+	},
+	// projectiles have behaviors and their own speed, the targets a swing hit
+	// moved to HitTargets
+	12: func(data *SaveData) error {
+		for i := 0; i < len(data.Entities); i++ {
+			var saved *savedEntity = &data.Entities[i]
+			if saved.IsProjectile {
+				saved.ProjectileSpeed = float32(saved.Speed)
+			}
+			if saved.SwingHits != nil {
+				saved.HitTargets = *saved.SwingHits
+				saved.SwingHits = nil
+			}
+		}
+		return nil
+	},
+	// sprite ids are no longer saved, LoadWorld resolves them from the
+	// archetypes whatever an older save holds
+	13: func(data *SaveData) error {
+		return nil
+	},
+}
+
+// savedEntity is an Entity with its unexported fields spelled out so both
+// encoders see them.
+type savedEntity struct {
+	Entity
+	InputAxis    Vector2
+	IsMelee      bool
+	IsProjectile bool
+	SpawnSerial  uint64
+
+	DetonateOnImpact   bool
+	DetonateAtMaxRange bool
+
+	// HitTargets as version 12 and older saves called it
+	SwingHits *[MAX_HIT_TARGETS]EntityHandle `json:",omitempty"`
+}
+
+type savedAllocator struct {
+	Free        []int32
+	Generations []uint32
+	Stats       AllocatorStats
+}
+
+type SaveData struct {
+	Version int
+	Config  Config
+
+	Tick            uint64
+	Random          Random
+	SpawnTimers     []float32
+	Player          EntityHandle
+	GrabbedCard     EntityHandle
+	CameraTarget    Vector2
+	SpawnSerial     uint64
+	Entities        []savedEntity
+	EntityAllocator savedAllocator
+	HandCards       []savedEntity
+	HandAllocator   savedAllocator
+	Deck            Deck
+	Lives           int32
+	DeathTimer      float32
+	GameOver        bool
+	Stats           RunStats
+
+	// version 1 only, read by its migration
+	ElapsedTimeGoblin float32 `json:",omitempty"`
+	ElapsedTimeTroll  float32 `json:",omitempty"`
+}
+
+// saveEntity leaves out the SpriteId, it indexes the sprites registered by the
+// running build and is resolved from the archetype on load.
+func saveEntity(en *Entity) savedEntity {
+	var entity Entity = *en
+	entity.SpriteId = SPRITE_NIL
+	return savedEntity{
+		Entity:       entity,
+		InputAxis:    en.inputAxis,
+		IsMelee:      en.isMelee,
+		IsProjectile: en.isProjectile,
+		SpawnSerial:  en.spawnSerial,
+
+		DetonateOnImpact:   en.detonateOnImpact,
+		DetonateAtMaxRange: en.detonateAtMaxRange,
+	}
+}
+
+func loadEntity(saved savedEntity) Entity {
+	var en Entity = saved.Entity
+	en.inputAxis = saved.InputAxis
+	en.isMelee = saved.IsMelee
+	en.isProjectile = saved.IsProjectile
+	en.spawnSerial = saved.SpawnSerial
+	en.detonateOnImpact = saved.DetonateOnImpact
+	en.detonateAtMaxRange = saved.DetonateAtMaxRange
+	return en
+}
+
+func saveAllocator(allocator *slotAllocator) savedAllocator {
+	return savedAllocator{
+		Free:        append([]int32(nil), allocator.free...),
+		Generations: append([]uint32(nil), allocator.generations...),
+		Stats:       allocator.stats,
+	}
+}
+
+func loadAllocator(allocator *slotAllocator, saved savedAllocator) error {
+	if len(saved.Generations) != len(allocator.generations) {
+		return fmt.Errorf("save has %d slots, this build has %d", len(saved.Generations), len(allocator.generations))
+	}
+	for _, index := range saved.Free {
+		if index < 0 || int(index) >= len(allocator.generations) {
+			return fmt.Errorf("save has free slot %d out of range", index)
+		}
+	}
+	allocator.free = append(allocator.free[:0], saved.Free...)
+	copy(allocator.generations, saved.Generations)
+	allocator.stats = saved.Stats
+	return nil
+}
+
+// saveConfig leaves out the SpriteId of every archetype, the same way
+// saveEntity does.
+func saveConfig(config Config) Config {
+	config.Archetypes = maps.Clone(config.Archetypes)
+	for name, archetype := range config.Archetypes {
+		archetype.SpriteId = SPRITE_NIL
+		config.Archetypes[name] = archetype
+	}
+	return config
+}
+
+// resolveSprites looks the sprite of every archetype up by name, and gives
+// every entity the sprite of its archetype.
+func resolveSprites(data *SaveData) error {
+	for name, archetype := range data.Config.Archetypes {
+		spriteId, err := spriteByNameOrPath(archetype.Sprite)
+		if err != nil {
+			return fmt.Errorf("archetype %q: %w", name, err)
+		}
+		archetype.SpriteId = spriteId
+		data.Config.Archetypes[name] = archetype
+	}
+
+	var entities []*savedEntity = nil
+	for i := 0; i < len(data.Entities); i++ {
+		entities = append(entities, &data.Entities[i])
+	}
+	for i := 0; i < len(data.HandCards); i++ {
+		entities = append(entities, &data.HandCards[i])
+	}
+	for _, saved := range entities {
+		archetype, ok := data.Config.Archetypes[saved.Archetype]
+		if !ok {
+			return fmt.Errorf("save has an entity of unknown archetype %q", saved.Archetype)
+		}
+		saved.SpriteId = archetype.SpriteId
+	}
+	return nil
+}
+
+// Save captures everything needed to continue the run: every valid entity,
+// the hand, spawn timers, camera and the RNG state.
+func (world *World) Save() *SaveData {
+	var data *SaveData = &SaveData{
+		Version:         SAVE_VERSION,
+		Config:          saveConfig(world.Config),
+		Tick:            world.Tick,
+		Random:          world.Random,
+		SpawnTimers:     append([]float32(nil), world.spawnTimers...),
+		Player:          world.Player,
+		GrabbedCard:     world.GrabbedCard,
+		CameraTarget:    world.CameraTarget,
+		SpawnSerial:     world.spawnSerial,
+		Lives:           world.Lives,
+		DeathTimer:      world.DeathTimer,
+		GameOver:        world.GameOver,
+		Stats:           world.Stats,
+		EntityAllocator: saveAllocator(&world.allocator),
+		HandAllocator:   saveAllocator(&world.Hand.allocator),
+		Deck: Deck{
+			DrawPile:    slices.Clone(world.Deck.DrawPile),
+			DiscardPile: slices.Clone(world.Deck.DiscardPile),
+			DrawTimer:   world.Deck.DrawTimer,
+		},
+	}
+
+	for i := 0; i < MAX_ENTITY_COUNT; i++ {
+		if world.Entities[i].IsValid {
+			data.Entities = append(data.Entities, saveEntity(&world.Entities[i]))
+		}
+	}
+	for i := 0; i < MAX_HAND_COUNT; i++ {
+		if world.Hand.Cards[i].IsValid {
+			data.HandCards = append(data.HandCards, saveEntity(&world.Hand.Cards[i]))
+		}
+	}
+	return data
+}
+
+// LoadWorld builds the world a save was taken from, migrating older saves
+// first.
+func LoadWorld(data *SaveData) (*World, error) {
+	if err := migrateSave(data); err != nil {
+		return nil, err
+	}
+	if err := resolveSprites(data); err != nil {
+		return nil, err
+	}
+
+	var world *World = newEmptyWorld(data.Config)
+	world.Tick = data.Tick
+	world.Random = data.Random
+	world.spawnTimers = spawnTimersFor(data.Config.Spawners, data.SpawnTimers)
+	world.Player = data.Player
+	world.GrabbedCard = data.GrabbedCard
+	world.Deck = data.Deck
+	world.CameraTarget = data.CameraTarget
+	world.PreviousCameraTarget = data.CameraTarget
+	world.spawnSerial = data.SpawnSerial
+	world.Lives = data.Lives
+	world.DeathTimer = data.DeathTimer
+	world.GameOver = data.GameOver
+	world.Stats = data.Stats
+
+	if err := loadAllocator(&world.allocator, data.EntityAllocator); err != nil {
+		return nil, fmt.Errorf("entities: %w", err)
+	}
+	if err := loadAllocator(&world.Hand.allocator, data.HandAllocator); err != nil {
+		return nil, fmt.Errorf("hand: %w", err)
+	}
+
+	for _, saved := range data.Entities {
+		var index int32 = saved.Handle.Index
+		if index < 0 || index >= MAX_ENTITY_COUNT {
+			return nil, fmt.Errorf("save has entity slot %d out of range", index)
+		}
+		world.Entities[index] = loadEntity(saved)
+		world.Entities[index].PreviousPosition = saved.Position
+	}
+	for _, saved := range data.HandCards {
+		var index int32 = saved.Handle.Index
+		if index < 0 || index >= MAX_HAND_COUNT {
+			return nil, fmt.Errorf("save has hand slot %d out of range", index)
+		}
+		world.Hand.Cards[index] = loadEntity(saved)
+	}
+
+	if _, ok := world.Get(world.Player); !ok {
+		return nil, errors.New("save has no player")
+	}
+	return world, nil
+}
+
+func migrateSave(data *SaveData) error {
+	if data.Version > SAVE_VERSION {
+		return fmt.Errorf("save version %d is newer than this build (version %d)", data.Version, SAVE_VERSION)
+	}
+	for data.Version < SAVE_VERSION {
+		migration, ok := saveMigrations[data.Version]
+		if !ok {
+			return fmt.Errorf("save version %d is too old for this build (version %d)", data.Version, SAVE_VERSION)
+		}
+		if err := migration(data); err != nil {
+			return fmt.Errorf("migrating save from version %d: %w", data.Version, err)
+		}
+		data.Version += 1
+	}
+	return nil
+}
+
+func WriteSave(w io.Writer, data *SaveData, format SaveFormat) error {
+	switch format {
+	case SAVE_FORMAT_JSON:
+		var encoder *json.Encoder = json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	default:
+		if _, err := io.WriteString(w, SAVE_MAGIC); err != nil {
+			return err
+		}
+		return gob.NewEncoder(w).Encode(data)
+	}
+}
+
+func ReadSave(r io.Reader) (*SaveData, error) {
+	var reader *bufio.Reader = bufio.NewReader(r)
+	var data *SaveData = &SaveData{}
+
+	magic, err := reader.Peek(len(SAVE_MAGIC))
+	if err == nil && string(magic) == SAVE_MAGIC {
+		reader.Discard(len(SAVE_MAGIC))
+		if err := gob.NewDecoder(reader).Decode(data); err != nil {
+			return nil, fmt.Errorf("reading save: %w", err)
+		}
+		return data, nil
+	}
+
+	first, err := reader.Peek(1)
+	if err != nil || first[0] != '{' {
+		return nil, ErrSaveFormat
+	}
+	if err := json.NewDecoder(reader).Decode(data); err != nil {
+		return nil, fmt.Errorf("reading save: %w", err)
+	}
+	return data, nil
+}
+
+// :save slots
+
+// slot 0 is the autosave, the hotkey slots start at 1
+const (
+	AUTOSAVE_SLOT  = 0
+	SAVE_SLOT_MAX  = 3
+	saveFileSuffix = ".sav"
+)
+
+type SaveSlots struct {
+	Dir    string
+	Format SaveFormat
+}
+
+func (slots SaveSlots) Path(slot int) string {
+	if slot == AUTOSAVE_SLOT {
+		return filepath.Join(slots.Dir, "autosave"+saveFileSuffix)
+	}
+	return filepath.Join(slots.Dir, fmt.Sprintf("slot%d%s", slot, saveFileSuffix))
+}
+
+// Store writes to a temporary file first so a crash mid save never leaves a
+// slot half written.
+func (slots SaveSlots) Store(slot int, world *World) error {
+	if err := os.MkdirAll(slots.Dir, 0o755); err != nil {
+		return err
+	}
+
+	var path string = slots.Path(slot)
+	file, err := os.CreateTemp(slots.Dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	var writer *bufio.Writer = bufio.NewWriter(file)
+	if err := WriteSave(writer, world.Save(), slots.Format); err != nil {
+		file.Close()
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+func (slots SaveSlots) Load(slot int) (*World, error) {
+	file, err := os.Open(slots.Path(slot))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := ReadSave(file)
+	if err != nil {
+		return nil, err
+	}
+	return LoadWorld(data)
+}
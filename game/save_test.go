@@ -0,0 +1,182 @@
+package game
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// roundTrip writes a save of world in format and loads it back.
+func roundTrip(t *testing.T, world *World, format SaveFormat) *World {
+	var file bytes.Buffer
+	if err := WriteSave(&file, world.Save(), format); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadSave(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadWorld(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded
+}
+
+func TestSaveRoundTripPlaysOnTheSame(t *testing.T) {
+	for _, format := range []SaveFormat{SAVE_FORMAT_JSON, SAVE_FORMAT_BINARY} {
+		var config Config = DefaultConfig()
+		config.Seed = 9
+		var world *World = NewWorld(config)
+		var random *rand.Rand = rand.New(rand.NewSource(9))
+		for i := 0; i < 300; i++ {
+			world.Step(world.TickDuration(), randomInput(world, random))
+		}
+
+		var loaded *World = roundTrip(t, world, format)
+		if loaded.Checksum() != world.Checksum() {
+			t.Fatalf("format %d: the loaded world differs from the saved one", format)
+		}
+		for i := 0; i < 300; i++ {
+			var in InputState = randomInput(world, random)
+			world.Step(world.TickDuration(), in)
+			loaded.Step(loaded.TickDuration(), in)
+			if loaded.Checksum() != world.Checksum() {
+				t.Fatalf("format %d: the loaded world diverged at tick %d", format, world.Tick)
+			}
+		}
+	}
+}
+
+// testdata/save_v1.json was written by the first build that saved, 300 ticks
+// into a run walking right. Every later version has to keep loading it.
+func TestSaveLoadsVersion1(t *testing.T) {
+	file, err := os.Open("testdata/save_v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	data, err := ReadSave(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Version != 1 {
+		t.Fatalf("fixture is version %d, expected 1", data.Version)
+	}
+
+	world, err := LoadWorld(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Version != SAVE_VERSION {
+		t.Fatalf("migrated up to version %d, this build is version %d", data.Version, SAVE_VERSION)
+	}
+	if world.Tick != 300 {
+		t.Fatalf("loaded world is at tick %d, the save was at 300", world.Tick)
+	}
+	playerEntity, ok := world.Get(world.Player)
+	if !ok {
+		t.Fatal("loaded world has no player")
+	}
+	if playerEntity.Archetype != "player" || playerEntity.MaxHealth == 0 || playerEntity.MaxMana == 0 || playerEntity.Faction != FACTION_PLAYER {
+		t.Fatalf("migration left the player without archetype, max health, mana or faction: %+v", playerEntity)
+	}
+	for i := 0; i < MAX_ENTITY_COUNT; i++ {
+		if world.Entities[i].IsValid && world.Entities[i].Archetype == "" {
+			t.Fatalf("migration left entity %d without an archetype", i)
+		}
+	}
+
+	var start Vector2 = playerEntity.Position
+	for i := 0; i < 300; i++ {
+		world.Step(world.TickDuration(), InputState{Axis: Vector2{X: 1}})
+	}
+	if playerEntity.Position.X <= start.X {
+		t.Fatalf("the player of a loaded world doesn't move, from %v to %v", start, playerEntity.Position)
+	}
+
+	// and the loaded world saves as the current version
+	var resaved *World = roundTrip(t, world, SAVE_FORMAT_BINARY)
+	if resaved.Checksum() != world.Checksum() {
+		t.Fatal("the loaded world doesn't round trip")
+	}
+}
+
+func TestSaveMigrationDoesNotTouchTheDefaults(t *testing.T) {
+	var before map[string]Card = make(map[string]Card)
+	for name, card := range defaultCards {
+		card.Effects = append([]Effect(nil), card.Effects...)
+		before[name] = card
+	}
+
+	file, err := os.Open("testdata/save_v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	data, err := ReadSave(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	world, err := LoadWorld(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, card := range world.Config.Cards {
+		for i := 0; i < len(card.Effects); i++ {
+			card.Effects[i].Amount += 100
+		}
+		world.Config.Cards[name] = card
+	}
+
+	for name, card := range defaultCards {
+		for i := 0; i < len(card.Effects); i++ {
+			if card.Effects[i] != before[name].Effects[i] {
+				t.Fatalf("editing a migrated card changed the built in %q", name)
+			}
+		}
+	}
+}
+
+func TestSaveLeavesSpritesToTheArchetypes(t *testing.T) {
+	var world *World = NewWorld(DefaultConfig())
+	spawnAt(t, world, "goblin", Vector2{X: 50})
+
+	var file bytes.Buffer
+	if err := WriteSave(&file, world.Save(), SAVE_FORMAT_JSON); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(file.Bytes(), []byte("SpriteId")) {
+		t.Fatal("the save holds sprite ids")
+	}
+
+	// ids saved by a build that registered its sprites in another order
+	var data *SaveData = world.Save()
+	for i := 0; i < len(data.Entities); i++ {
+		data.Entities[i].SpriteId = 999
+	}
+	var archetype Archetype = data.Config.Archetypes["goblin"]
+	archetype.SpriteId = 999
+	data.Config.Archetypes["goblin"] = archetype
+
+	loaded, err := LoadWorld(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < MAX_ENTITY_COUNT; i++ {
+		var entity *Entity = &loaded.Entities[i]
+		if entity.IsValid && entity.SpriteId != world.Config.Archetypes[entity.Archetype].SpriteId {
+			t.Fatalf("loaded %s with sprite %d, its archetype has %d", entity.Archetype, entity.SpriteId, world.Config.Archetypes[entity.Archetype].SpriteId)
+		}
+	}
+	if loaded.Config.Archetypes["goblin"].SpriteId != SPRITE_GOBLIN {
+		t.Fatalf("loaded the goblin archetype with sprite %d", loaded.Config.Archetypes["goblin"].SpriteId)
+	}
+	for i := 0; i < MAX_HAND_COUNT; i++ {
+		var card *Entity = &loaded.Hand.Cards[i]
+		if card.IsValid && card.SpriteId != world.Config.Archetypes[card.Archetype].SpriteId {
+			t.Fatalf("loaded card %s with sprite %d", card.Archetype, card.SpriteId)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package game
+
+import "math"
+
+// cells are a whole number of tiles wide, big enough that most entities only
+// touch one or two of them
+const SPATIAL_CELL_TILES int32 = 4
+
+type spatialCell struct {
+	X int32
+	Y int32
+}
+
+// spatialHash is a uniform grid broadphase over World.Entities. It is rebuilt
+// from scratch every tick, entities are inserted in every cell their bounds
+// touch, and queries return candidate handles that callers still have to
+// narrow down with an exact check.
+type spatialHash struct {
+	cellSize float32
+	cells    map[spatialCell][]EntityHandle
+
+	// an entity spanning several cells is only reported once per query
+	marks [MAX_ENTITY_COUNT]uint32
+	stamp uint32
+}
+
+func newSpatialHash() spatialHash {
+	return spatialHash{
+		cellSize: float32(TILE_WIDTH * SPATIAL_CELL_TILES),
+		cells:    make(map[spatialCell][]EntityHandle),
+	}
+}
+
+// entityBounds is the collision rectangle grown to include the entity
+// position, cards have no collision size but are still picked by position.
+func entityBounds(en *Entity) Rectangle {
+	var minX float32 = float32(math.Min(float64(en.CollisionRectangle.X), float64(en.Position.X)))
+	var minY float32 = float32(math.Min(float64(en.CollisionRectangle.Y), float64(en.Position.Y)))
+	var maxX float32 = float32(math.Max(float64(en.CollisionRectangle.X+en.CollisionRectangle.Width), float64(en.Position.X)))
+	var maxY float32 = float32(math.Max(float64(en.CollisionRectangle.Y+en.CollisionRectangle.Height), float64(en.Position.Y)))
+	return Rectangle{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+func (hash *spatialHash) cellOf(x, y float32) spatialCell {
+	return spatialCell{
+		X: int32(math.Floor(float64(x / hash.cellSize))),
+		Y: int32(math.Floor(float64(y / hash.cellSize))),
+	}
+}
+
+func (hash *spatialHash) rebuild(entities []Entity) {
+	// keep the buckets around between ticks, only forget cells that stayed
+	// empty for a whole tick so the map doesn't grow with everywhere the
+	// player has been
+	for cell, bucket := range hash.cells {
+		if len(bucket) == 0 {
+			delete(hash.cells, cell)
+		} else {
+			hash.cells[cell] = bucket[:0]
+		}
+	}
+
+	for i := 0; i < len(entities); i++ {
+		var en *Entity = &entities[i]
+		if en.IsValid {
+			hash.insert(en)
+		}
+	}
+}
+
+func (hash *spatialHash) insert(en *Entity) {
+	var bounds Rectangle = entityBounds(en)
+	var minCell spatialCell = hash.cellOf(bounds.X, bounds.Y)
+	var maxCell spatialCell = hash.cellOf(bounds.X+bounds.Width, bounds.Y+bounds.Height)
+
+	for x := minCell.X; x <= maxCell.X; x++ {
+		for y := minCell.Y; y <= maxCell.Y; y++ {
+			var cell spatialCell = spatialCell{X: x, Y: y}
+			hash.cells[cell] = append(hash.cells[cell], en.Handle)
+		}
+	}
+}
+
+func (hash *spatialHash) queryRect(rect Rectangle, result []EntityHandle) []EntityHandle {
+	var minCell spatialCell = hash.cellOf(rect.X, rect.Y)
+	var maxCell spatialCell = hash.cellOf(rect.X+rect.Width, rect.Y+rect.Height)
+
+	hash.stamp += 1
+	for x := minCell.X; x <= maxCell.X; x++ {
+		for y := minCell.Y; y <= maxCell.Y; y++ {
+			for _, handle := range hash.cells[spatialCell{X: x, Y: y}] {
+				if hash.marks[handle.Index] == hash.stamp {
+					continue
+				}
+				hash.marks[handle.Index] = hash.stamp
+				result = append(result, handle)
+			}
+		}
+	}
+	return result
+}
+
+// QueryRect appends to result the handles of entities whose bounds may overlap
+// rect.
+func (world *World) QueryRect(rect Rectangle, result []EntityHandle) []EntityHandle {
+	return world.spatial.queryRect(rect, result)
+}
+
+// QueryCircle appends to result the handles of entities whose bounds may
+// overlap the circle.
+func (world *World) QueryCircle(center Vector2, radius float32, result []EntityHandle) []EntityHandle {
+	var rect Rectangle = Rectangle{X: center.X - radius, Y: center.Y - radius, Width: radius * 2, Height: radius * 2}
+	var start int = len(result)
+	result = world.spatial.queryRect(rect, result)
+
+	// drop the candidates that are only inside the square corners
+	var kept int = start
+	for _, handle := range result[start:] {
+		if CheckCollisionCircleRec(center, radius, entityBounds(&world.Entities[handle.Index])) {
+			result[kept] = handle
+			kept += 1
+		}
+	}
+	return result[:kept]
+}
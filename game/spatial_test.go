@@ -0,0 +1,96 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// benchmarkWorld fills every slot: a quarter attacks, the rest goblins spread
+// over roughly the area the camera shows.
+func benchmarkWorld() *World {
+	var world *World = NewWorld(DefaultConfig())
+	var random *rand.Rand = rand.New(rand.NewSource(1))
+
+	for i := 0; ; i++ {
+		var position Vector2 = Vector2{X: random.Float32()*400 - 200, Y: random.Float32()*300 - 150}
+		var archetype string = "goblin"
+		if i%4 == 0 {
+			archetype = "attack_fireball"
+		}
+		if _, err := world.spawnArchetype(archetype, position); err != nil {
+			break
+		}
+	}
+	return world
+}
+
+// bruteForceCollisions is the :collision pass as it was before the broadphase,
+// counting hits instead of applying them so every iteration does the same work.
+func bruteForceCollisions(world *World) int {
+	var hits int = 0
+	for i := 0; i < MAX_ENTITY_COUNT; i++ {
+		var firstEntity *Entity = &world.Entities[i]
+		if firstEntity.Type == ARCH_ATTACK {
+			for j := 0; j < MAX_ENTITY_COUNT; j++ {
+				var secondEntity *Entity = &world.Entities[j]
+				if firstEntity == secondEntity {
+					continue
+				}
+				if secondEntity.Type == ARCH_ATTACK || secondEntity.Type == ARCH_CARD || secondEntity.Type == ARCH_PLAYER {
+					continue
+				}
+				if CheckCollisionRecs(firstEntity.CollisionRectangle, secondEntity.CollisionRectangle) {
+					hits += 1
+				}
+			}
+		}
+	}
+	return hits
+}
+
+func broadphaseCollisions(world *World) int {
+	var hits int = 0
+	world.spatial.rebuild(world.Entities[:])
+	for i := 0; i < MAX_ENTITY_COUNT; i++ {
+		var firstEntity *Entity = &world.Entities[i]
+		if firstEntity.Type == ARCH_ATTACK {
+			world.queryBuffer = world.QueryRect(firstEntity.CollisionRectangle, world.queryBuffer[:0])
+			for _, handle := range world.queryBuffer {
+				secondEntity, ok := world.Get(handle)
+				if !ok || firstEntity == secondEntity {
+					continue
+				}
+				if secondEntity.Type == ARCH_ATTACK || secondEntity.Type == ARCH_CARD || secondEntity.Type == ARCH_PLAYER {
+					continue
+				}
+				if CheckCollisionRecs(firstEntity.CollisionRectangle, secondEntity.CollisionRectangle) {
+					hits += 1
+				}
+			}
+		}
+	}
+	return hits
+}
+
+func TestBroadphaseMatchesBruteForce(t *testing.T) {
+	var world *World = benchmarkWorld()
+	if brute, broad := bruteForceCollisions(world), broadphaseCollisions(world); brute != broad {
+		t.Fatalf("brute force found %d hits, broadphase found %d", brute, broad)
+	}
+}
+
+func BenchmarkCollisionsBruteForce1024(b *testing.B) {
+	var world *World = benchmarkWorld()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bruteForceCollisions(world)
+	}
+}
+
+func BenchmarkCollisionsBroadphase1024(b *testing.B) {
+	var world *World = benchmarkWorld()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		broadphaseCollisions(world)
+	}
+}
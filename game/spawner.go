@@ -0,0 +1,111 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"duelingMonsters/data"
+)
+
+// Spawner brings in Amount entities of an archetype every Rate seconds, one
+// entry of data/spawns.json.
+type Spawner struct {
+	Archetype string  `json:"archetype"`
+	Rate      float32 `json:"rate"`
+	Amount    int32   `json:"amount"`
+	Position  Vector2 `json:"position"`
+	// every further entity of a wave is placed this much further along both axes
+	Spacing float32 `json:"spacing"`
+	// spawned entities land up to this far from their position
+	Jitter float32 `json:"jitter"`
+}
+
+func (spawner *Spawner) validate(archetypes map[string]Archetype) error {
+	if _, ok := archetypes[spawner.Archetype]; !ok {
+		return fmt.Errorf("unknown archetype %q", spawner.Archetype)
+	}
+	if spawner.Rate <= 0 {
+		return errors.New("rate must be above 0 seconds")
+	}
+	if spawner.Amount < 0 || spawner.Jitter < 0 {
+		return errors.New("amount and jitter can't be negative")
+	}
+	return nil
+}
+
+// ParseSpawners reads a spawn file, a JSON list of spawners. Every spawner has
+// to name one of archetypes. file is only used in error messages.
+func ParseSpawners(file string, text []byte, archetypes map[string]Archetype) ([]Spawner, error) {
+	var spawners []Spawner = []Spawner{}
+	err := decodeDataEntries(file, text, '[', "spawner", func(name string, spawner *Spawner) error {
+		if err := spawner.validate(archetypes); err != nil {
+			return err
+		}
+		spawners = append(spawners, *spawner)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return spawners, nil
+}
+
+func LoadSpawners(path string, archetypes map[string]Archetype) ([]Spawner, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSpawners(path, text, archetypes)
+}
+
+// :globals default spawners
+var defaultSpawners []Spawner
+
+func init() {
+	text, err := data.Files.ReadFile(data.SpawnsFile)
+	assert(err == nil, "embedded "+data.SpawnsFile+" missing")
+	defaultSpawners, err = ParseSpawners(filepath.Join("data", data.SpawnsFile), text, defaultArchetypes)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// :spawn spawners
+// world.spawnTimers[i] is the time since Config.Spawners[i] last spawned
+
+func (world *World) runSpawners() {
+	for i := 0; i < len(world.Config.Spawners); i++ {
+		var spawner *Spawner = &world.Config.Spawners[i]
+		if world.spawnTimers[i] < spawner.Rate {
+			continue
+		}
+
+		for j := int32(0); j < spawner.Amount; j++ {
+			var position Vector2 = Vector2AddValue(spawner.Position, float32(j)*spawner.Spacing)
+			position = world.jitterSpawnPosition(position, spawner.Jitter)
+			if _, err := world.spawnArchetype(spawner.Archetype, position); err != nil {
+				break
+			}
+		}
+		world.spawnTimers[i] = 0
+	}
+}
+
+func (world *World) jitterSpawnPosition(position Vector2, jitter float32) Vector2 {
+	if jitter <= 0 {
+		return position
+	}
+	position.X += world.Random.Float32Range(-jitter, jitter)
+	position.Y += world.Random.Float32Range(-jitter, jitter)
+	return RoundV2ToTile(position)
+}
+
+// spawnTimersFor keeps the timers of spawners that are still at the same
+// index, new ones start from 0.
+func spawnTimersFor(spawners []Spawner, timers []float32) []float32 {
+	var resized []float32 = make([]float32, len(spawners))
+	copy(resized, timers)
+	return resized
+}
@@ -0,0 +1,274 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"duelingMonsters/data"
+)
+
+const MAX_STATUS_COUNT = 4
+
+// :enum StackingPolicy
+// what applying a status an entity is already under does
+type StackingPolicy int
+
+const (
+	// the duration starts over, if that makes it longer
+	STACKING_REFRESH StackingPolicy = 0
+	// one more stack up to MaxStacks, ticks and shields add up
+	STACKING_STACK StackingPolicy = 1
+	// nothing, the first one runs its course
+	STACKING_IGNORE StackingPolicy = 2
+)
+
+var stackingPolicyNames = map[string]StackingPolicy{
+	"refresh": STACKING_REFRESH,
+	"stack":   STACKING_STACK,
+	"ignore":  STACKING_IGNORE,
+}
+
+// Status is one entry of data/statuses.json. Stacking is a name in the file
+// and is resolved while decoding.
+type Status struct {
+	// drawn over the entity under it, a letter or two on a square of Color
+	Icon  string   `json:"icon"`
+	Color [3]uint8 `json:"color"`
+	// seconds, effects applying the status without a duration of their own
+	// use this one
+	Duration float32 `json:"duration"`
+
+	// Damage of DamageType every TickInterval seconds, per stack
+	TickInterval float32    `json:"tick_interval"`
+	Damage       int32      `json:"damage"`
+	DamageType   DamageType `json:"damage_type"`
+
+	// 1 when left out
+	SpeedMultiplier float32 `json:"speed_multiplier"`
+	BlocksMovement  bool    `json:"blocks_movement"`
+	BlocksCasting   bool    `json:"blocks_casting"`
+	// damage absorbed per stack before health is touched, the status ends
+	// once it is used up
+	Shield int32 `json:"shield"`
+
+	Stacking  string `json:"stacking"`
+	MaxStacks int32  `json:"max_stacks"`
+
+	Policy StackingPolicy `json:"-"`
+}
+
+func (status *Status) UnmarshalJSON(text []byte) error {
+	type plainStatus Status
+	var plain plainStatus = plainStatus{SpeedMultiplier: 1}
+	var decoder *json.Decoder = json.NewDecoder(bytes.NewReader(text))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&plain); err != nil {
+		return err
+	}
+	*status = Status(plain)
+
+	policy, ok := stackingPolicyNames[status.Stacking]
+	if !ok && status.Stacking != "" {
+		return fmt.Errorf("unknown stacking %q, use refresh, stack or ignore", status.Stacking)
+	}
+	status.Policy = policy
+	return nil
+}
+
+func (status *Status) validate() error {
+	if status.Duration < 0 || status.TickInterval < 0 || status.Damage < 0 || status.Shield < 0 || status.SpeedMultiplier < 0 {
+		return errors.New("duration, tick_interval, damage, shield and speed_multiplier can't be negative")
+	}
+	if status.Damage > 0 && status.TickInterval == 0 {
+		return errors.New("damage needs a tick_interval to be dealt every")
+	}
+	if status.Policy == STACKING_STACK && status.MaxStacks < 1 {
+		return errors.New("stacking needs max_stacks of at least 1")
+	}
+	if status.Icon == "" {
+		return errors.New("needs an icon")
+	}
+	return nil
+}
+
+// ParseStatuses reads a status file, a JSON object of name to definition.
+// file is only used in error messages.
+func ParseStatuses(file string, text []byte) (map[string]Status, error) {
+	var statuses map[string]Status = make(map[string]Status)
+	err := decodeDataEntries(file, text, '{', "status", func(name string, status *Status) error {
+		if _, ok := statuses[name]; ok {
+			return errors.New("defined twice")
+		}
+		if err := status.validate(); err != nil {
+			return err
+		}
+		statuses[name] = *status
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+func LoadStatuses(path string) (map[string]Status, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStatuses(path, text)
+}
+
+// :globals default statuses
+// a variable initializer rather than init, the cards are validated against
+// the statuses in the init of card.go
+var defaultStatuses map[string]Status = mustParseStatuses()
+
+func mustParseStatuses() map[string]Status {
+	text, err := data.Files.ReadFile(data.StatusesFile)
+	assert(err == nil, "embedded "+data.StatusesFile+" missing")
+	statuses, err := ParseStatuses(filepath.Join("data", data.StatusesFile), text)
+	if err != nil {
+		panic(err)
+	}
+	return statuses
+}
+
+// :status effects
+
+// StatusEffect is a status an entity is under. TickTimer counts down to the
+// next tick of damage and Shield is what is left to absorb.
+type StatusEffect struct {
+	Name      string
+	Remaining float32
+	Stacks    int32
+	TickTimer float32
+	Shield    int32
+}
+
+// applyStatus puts en under the status for duration seconds, or the status'
+// own duration when 0. A status en is already under stacks by its policy,
+// otherwise it takes a free slot, or the slot of the status closest to running
+// out when all are taken.
+func (world *World) applyStatus(en *Entity, name string, duration float32) {
+	definition, ok := world.Config.Statuses[name]
+	if !ok {
+		return
+	}
+	if duration == 0 {
+		duration = definition.Duration
+	}
+
+	for i := 0; i < MAX_STATUS_COUNT; i++ {
+		var status *StatusEffect = &en.Statuses[i]
+		if status.Name != name {
+			continue
+		}
+		switch definition.Policy {
+		case STACKING_REFRESH:
+			status.Remaining = max(status.Remaining, duration)
+		case STACKING_STACK:
+			if status.Stacks < definition.MaxStacks {
+				status.Stacks += 1
+				status.Shield += definition.Shield
+			}
+			status.Remaining = duration
+		}
+		return
+	}
+
+	var slot *StatusEffect = &en.Statuses[0]
+	for i := 0; i < MAX_STATUS_COUNT; i++ {
+		var status *StatusEffect = &en.Statuses[i]
+		if status.Name == "" {
+			slot = status
+			break
+		}
+		if status.Remaining < slot.Remaining {
+			slot = status
+		}
+	}
+	*slot = StatusEffect{Name: name, Remaining: duration, Stacks: 1, TickTimer: definition.TickInterval, Shield: definition.Shield}
+}
+
+// updateStatuses ticks the damage of the statuses en is under and ends those
+// that ran out. A status whose definition is gone, after a reload, ends too.
+// Statuses live in the entity, destroying it ends them all.
+func (world *World) updateStatuses(en *Entity, delta_t float32) {
+	for i := 0; i < MAX_STATUS_COUNT; i++ {
+		var status *StatusEffect = &en.Statuses[i]
+		if status.Name == "" {
+			continue
+		}
+		definition, ok := world.Config.Statuses[status.Name]
+		if !ok {
+			*status = StatusEffect{}
+			continue
+		}
+
+		var elapsed float32 = min(delta_t, status.Remaining)
+		if definition.Damage > 0 {
+			status.TickTimer -= elapsed
+			for status.TickTimer <= 0 {
+				// damage over time has no attacker to scale it
+				world.applyDamage(nil, en, definition.Damage*status.Stacks, definition.DamageType)
+				status.TickTimer += definition.TickInterval
+			}
+		}
+
+		status.Remaining -= delta_t
+		if status.Remaining <= 0 || (definition.Shield > 0 && status.Shield <= 0) {
+			*status = StatusEffect{}
+		}
+	}
+}
+
+// speedMultiplier is what the statuses en is under do to its Speed, 0 while
+// one blocks movement or en is hit stunned.
+func (world *World) speedMultiplier(en *Entity) float32 {
+	if en.HitStunTimer > 0 {
+		return 0
+	}
+	var multiplier float32 = 1
+	for i := 0; i < MAX_STATUS_COUNT; i++ {
+		if en.Statuses[i].Name == "" {
+			continue
+		}
+		var definition Status = world.Config.Statuses[en.Statuses[i].Name]
+		if definition.BlocksMovement {
+			return 0
+		}
+		multiplier *= definition.SpeedMultiplier
+	}
+	return multiplier
+}
+
+// castingBlockedBy names the status keeping en from casting and attacking.
+func (world *World) castingBlockedBy(en *Entity) (string, bool) {
+	for i := 0; i < MAX_STATUS_COUNT; i++ {
+		var name string = en.Statuses[i].Name
+		if name != "" && world.Config.Statuses[name].BlocksCasting {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// absorbDamage takes amount out of the shields of en first, returns what is
+// left for its health.
+func absorbDamage(en *Entity, amount int32) int32 {
+	for i := 0; i < MAX_STATUS_COUNT && amount > 0; i++ {
+		var status *StatusEffect = &en.Statuses[i]
+		if status.Name == "" || status.Shield <= 0 {
+			continue
+		}
+		var absorbed int32 = min(status.Shield, amount)
+		status.Shield -= absorbed
+		amount -= absorbed
+	}
+	return amount
+}
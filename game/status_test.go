@@ -0,0 +1,95 @@
+package game
+
+import "testing"
+
+// statusOf is the status en is under by name, nil when it isn't.
+func statusOf(en *Entity, name string) *StatusEffect {
+	for i := 0; i < MAX_STATUS_COUNT; i++ {
+		if en.Statuses[i].Name == name {
+			return &en.Statuses[i]
+		}
+	}
+	return nil
+}
+
+func TestStatusStackingPolicies(t *testing.T) {
+	world, _ := testWorld()
+	var goblin *Entity = dummyAt(t, world, Vector2{X: 50})
+
+	// burn refreshes, only ever to a longer duration
+	world.applyStatus(goblin, "burn", 1)
+	world.applyStatus(goblin, "burn", 3)
+	world.applyStatus(goblin, "burn", 2)
+	if burn := statusOf(goblin, "burn"); burn.Remaining != 3 || burn.Stacks != 1 {
+		t.Fatalf("refreshed burn has %v left in %d stacks, expected 3 in 1", burn.Remaining, burn.Stacks)
+	}
+
+	// poison stacks up to its max, each stack starting the duration over
+	for i := 0; i < 7; i++ {
+		world.applyStatus(goblin, "poison", 0)
+	}
+	var maxStacks int32 = world.Config.Statuses["poison"].MaxStacks
+	if poison := statusOf(goblin, "poison"); poison.Stacks != maxStacks || poison.Remaining != world.Config.Statuses["poison"].Duration {
+		t.Fatalf("poison applied 7 times has %d stacks and %v left, expected %d and its duration", poison.Stacks, poison.Remaining, maxStacks)
+	}
+
+	// a second stun is ignored, the first runs its course
+	world.applyStatus(goblin, "stun", 1)
+	world.updateStatuses(goblin, 0.5)
+	world.applyStatus(goblin, "stun", 1)
+	if stun := statusOf(goblin, "stun"); stun.Remaining != 0.5 || stun.Stacks != 1 {
+		t.Fatalf("the ignored stun left %v in %d stacks, expected 0.5 in 1", stun.Remaining, stun.Stacks)
+	}
+}
+
+func TestStatusTickDamageScalesWithStacks(t *testing.T) {
+	world, _ := testWorld()
+	var goblin *Entity = dummyAt(t, world, Vector2{X: 50})
+	for i := 0; i < 3; i++ {
+		world.applyStatus(goblin, "poison", 0)
+	}
+
+	// a little over one tick interval of poison
+	var definition Status = world.Config.Statuses["poison"]
+	for i := 0; i < int(definition.TickInterval*world.Config.TickRate)+1; i++ {
+		world.Step(world.TickDuration(), InputState{})
+	}
+	if taken := 1000 - goblin.Health; taken != definition.Damage*3 {
+		t.Fatalf("a tick of 3 stacks of poison did %d damage, expected %d", taken, definition.Damage*3)
+	}
+}
+
+func TestStatusShieldAbsorbsUntilUsedUp(t *testing.T) {
+	world, playerEntity := testWorld()
+	world.applyStatus(playerEntity, "shield", 0)
+	world.applyStatus(playerEntity, "shield", 0)
+	var shield int32 = world.Config.Statuses["shield"].Shield * 2
+	var health int32 = playerEntity.Health
+	var goblin *Entity = dummyAt(t, world, Vector2{X: 50})
+	goblin.CritChance = 0
+
+	var result DamageResult = world.applyDamage(goblin, playerEntity, shield-5, DAMAGE_PHYSICAL)
+	if result.Absorbed != shield-5 || result.Amount != 0 || playerEntity.Health != health {
+		t.Fatalf("a hit the shields cover absorbed %d and dealt %d", result.Absorbed, result.Amount)
+	}
+
+	// past the invulnerability the first hit left
+	playerEntity.InvulnerableTimer = 0
+	result = world.applyDamage(goblin, playerEntity, 10, DAMAGE_PHYSICAL)
+	if result.Absorbed != 5 || result.Amount != 5 || playerEntity.Health != health-5 {
+		t.Fatalf("the hit through the last of the shields absorbed %d and dealt %d", result.Absorbed, result.Amount)
+	}
+	world.updateStatuses(playerEntity, world.TickDuration())
+	if statusOf(playerEntity, "shield") != nil {
+		t.Fatal("the used up shield didn't end")
+	}
+}
+
+func TestRootedCasterCantDash(t *testing.T) {
+	world, playerEntity := testWorld()
+	world.applyStatus(playerEntity, "root", 0)
+	world.runEffects([]Effect{{Kind: EFFECT_DASH, Distance: 40}}, playerEntity, Vector2{X: 100}, &Entity{})
+	if playerEntity.Position != (Vector2{}) {
+		t.Fatalf("the rooted player dashed to %v", playerEntity.Position)
+	}
+}
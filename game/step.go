@@ -0,0 +1,334 @@
+package game
+
+import "math"
+
+// Step advances the simulation by one tick of delta_t seconds using the given
+// input. The game loop goes through Advance, headless runs may call it directly.
+func (world *World) Step(delta_t float32, in InputState) {
+	// :clean :reset
+	var hoveredCardLastTick EntityHandle = world.Frame.HoveredCard
+	world.Frame = WorldFrame{}
+	world.Tick += 1
+
+	// :interpolation previous state
+	var spawnSerialAtTickStart uint64 = world.spawnSerial
+	world.PreviousCameraTarget = world.CameraTarget
+	for i := 0; i < MAX_ENTITY_COUNT; i++ {
+		var entity *Entity = &world.Entities[i]
+		if entity.IsValid {
+			entity.PreviousPosition = entity.Position
+		}
+	}
+	for i := 0; i < MAX_HAND_COUNT; i++ {
+		var card *Entity = &world.Hand.Cards[i]
+		if card.IsValid {
+			card.PreviousPosition = card.Position
+		}
+	}
+
+	var config *Config = &world.Config
+	var runningMultiplier float32 = 1
+
+	playerEntity, ok := world.Get(world.Player)
+	assert(ok, "the player entity is never destroyed")
+
+	// :player death, the world stands still
+	if world.GameOver {
+		return
+	}
+	if world.DeathTimer > 0 {
+		world.DeathTimer -= delta_t
+		if world.DeathTimer <= 0 {
+			world.endDeath(playerEntity)
+		}
+		return
+	}
+	world.Stats.Ticks += 1
+
+	// :input
+	{
+		playerEntity.inputAxis = in.Axis
+		if in.Running {
+			runningMultiplier = 1.5
+		}
+	}
+
+	// :spawn Enemies
+	// the timers stand still with the rest of the world while the player is dead
+	{
+		for i := 0; i < len(world.spawnTimers); i++ {
+			world.spawnTimers[i] += delta_t
+		}
+		world.runSpawners()
+	}
+
+	// :camera
+	{
+		var target Vector2 = playerEntity.Position
+		var sprite = getSpriteSize(playerEntity.SpriteId)
+		target.X = target.X + (float32(sprite.Width))/2.0
+		target.Y = target.Y + (float32(sprite.Height))/2.0
+		animateV2ToTarget(&world.CameraTarget, target, delta_t, 30.0)
+	}
+
+	var mousePositionScreen Vector2 = in.MouseScreen
+	var mousePositionWorld Vector2 = in.MouseWorld
+
+	// :mouse :selector
+	{
+		world.Frame.HoveredCard = world.cardUnderMouse(mousePositionScreen, hoveredCardLastTick)
+
+		var smallestDistance float32 = math.MaxFloat32
+		var foundEntity bool = false
+
+		world.spatial.rebuild(world.Entities[:])
+		world.queryBuffer = world.QueryCircle(mousePositionWorld, entitySelectionRadius, world.queryBuffer[:0])
+		for _, handle := range world.queryBuffer {
+			if en, ok := world.Get(handle); ok {
+				var distance float32 = float32(math.Abs(float64(Vector2Distance(en.Position, mousePositionWorld))))
+				if distance < entitySelectionRadius {
+					if !foundEntity || (distance < smallestDistance) {
+						world.Frame.SelectedEntity = en.Handle
+						smallestDistance = distance
+						foundEntity = true
+					}
+				}
+			}
+		}
+
+	}
+
+	// :mouse :click handler
+	{
+
+		var top20Percent float32 = world.HandAreaTop()
+
+		if in.MouseLeftDown {
+			if hoveredCard, ok := world.Hand.Get(world.Frame.HoveredCard); ok && world.GrabbedCard == (EntityHandle{}) {
+				world.GrabbedCard = hoveredCard.Handle
+			}
+			// the aim of the grabbed card is previewed by the renderer, see
+			// World.AimPreview
+		} else if in.MouseRightPressed {
+			/* inputAxis = Vector2Subtract(terminalPoint, playerEntity.Position) */
+		}
+		if in.MouseLeftPressed {
+			/* basicAttack, err := world.spawnArchetype("attack_basic", playerEntity.Position) */
+			/* basicAttack.inputAxis = Vector2Normalize((Vector2Subtract(mousePositionWorld, playerEntity.Position))) */
+			/* basicAttack.MaxPosition = Vector2AddValue(playerEntity.Position, float32(basicAttack.Range)) */
+		}
+
+		if in.MouseLeftReleased {
+			if grabbedCard, ok := world.Hand.Get(world.GrabbedCard); ok {
+				// dropped back on the hand area the card just returns to it
+				if mousePositionScreen.Y < top20Percent {
+					world.playCard(grabbedCard, playerEntity, mousePositionWorld)
+				}
+
+				world.GrabbedCard = EntityHandle{}
+			}
+
+		}
+
+	}
+
+	// :update
+	var kills int32 = 0
+	{
+
+		for i := 0; i < MAX_ENTITY_COUNT; i++ {
+			var entity *Entity = &world.Entities[i]
+			if entity.Type == ARCH_ATTACK {
+				if entity.isMelee && entity.Angle >= entity.MaxAngle {
+					world.destroyEntity(entity)
+					continue
+				}
+				if entity.isProjectile && reachedMaxRange(entity) {
+					// held in place for :collision to blast it
+					if entity.detonateAtMaxRange {
+						continue
+					}
+					world.destroyEntity(entity)
+					continue
+				}
+			}
+			// :update :positions
+
+			world.updateStatuses(entity, delta_t)
+
+			if entity.Type == ARCH_PLAYER {
+				entity.Position = Vector2Add(entity.Position, Vector2Scale(entity.inputAxis, (float32(entity.Speed)*delta_t)*runningMultiplier*world.speedMultiplier(entity)))
+			} else if isEnemy(entity) {
+				world.updateAI(entity, playerEntity, delta_t)
+			} else if entity.Type == ARCH_ATTACK {
+				if entity.isMelee {
+					world.updateSwing(entity, delta_t)
+					if !entity.IsValid {
+						continue
+					}
+				} else {
+					world.updateProjectile(entity, delta_t)
+				}
+			}
+
+			// :update :knockback
+			if entity.Type != ARCH_ATTACK {
+				entity.Position = Vector2Add(entity.Position, Vector2Scale(entity.Velocity, delta_t))
+				entity.Velocity = Vector2Scale(entity.Velocity, float32(math.Exp(float64(-config.VelocityDecay*delta_t))))
+				if Vector2Length(entity.Velocity) < 1 {
+					entity.Velocity = Vector2{}
+				}
+				entity.HitStunTimer = max(entity.HitStunTimer-delta_t, 0)
+				entity.InvulnerableTimer = max(entity.InvulnerableTimer-delta_t, 0)
+			}
+
+			// :update :mana
+			if entity.MaxMana > 0 {
+				entity.Mana = min(entity.Mana+entity.ManaRegen*delta_t, float32(entity.MaxMana))
+			}
+
+			updateCollisionRectangle(entity)
+
+			// :update :existance
+			if entity == playerEntity {
+				if entity.Health <= 0 {
+					world.killPlayer(entity)
+				}
+				continue
+			}
+			if entity.IsValid && entity.Health <= 0 {
+				// the kills of the player's own hits, not those of a friendly
+				// fire hit between enemies
+				if entity.LastHitBy == world.Player {
+					kills += 1
+				}
+				world.destroyEntity(entity)
+			}
+		}
+
+	}
+
+	// :update :deck
+	{
+		world.Stats.Kills += kills
+		world.drawCards(kills * config.DrawOnKill)
+		world.updateDeck(delta_t)
+	}
+
+	// :player death, from the tick the player died nothing attacks or hits
+	// anymore
+	if world.DeathTimer > 0 || world.GameOver {
+		return
+	}
+
+	// :enemy :attack
+	// after :update so the attacks aren't moved on the tick they spawn, an
+	// enemy that went into AI_ATTACK this tick hasn't spent any time in it
+	{
+		for i := 0; i < MAX_ENTITY_COUNT; i++ {
+			var entity *Entity = &world.Entities[i]
+			if isEnemy(entity) && entity.AIState == AI_ATTACK && entity.AITimer == 0 && entity.HitStunTimer <= 0 {
+				// a stunned enemy winds up for nothing
+				if _, blocked := world.castingBlockedBy(entity); !blocked {
+					world.enemyAttack(entity, playerEntity)
+				}
+			}
+
+		}
+	}
+
+	// :collision
+	{
+		world.spatial.rebuild(world.Entities[:])
+
+		for i := 0; i < MAX_ENTITY_COUNT; i++ {
+
+			var firstEntity *Entity = &world.Entities[i]
+			if firstEntity.Type == ARCH_ATTACK {
+
+				if firstEntity.isProjectile && firstEntity.detonateAtMaxRange && reachedMaxRange(firstEntity) {
+					world.detonate(firstEntity, firstEntity.Position)
+					world.destroyEntity(firstEntity)
+					continue
+				}
+
+				// gathered first, the hits themselves query the spatial hash
+				world.hitBuffer = world.hitBuffer[:0]
+				world.queryBuffer = world.QueryRect(firstEntity.CollisionRectangle, world.queryBuffer[:0])
+				for _, handle := range world.queryBuffer {
+
+					secondEntity, ok := world.Get(handle)
+					if !ok || firstEntity == secondEntity {
+						continue
+					}
+
+					if !world.canHit(firstEntity, secondEntity) {
+						continue
+					}
+
+					if CheckCollisionRecs(firstEntity.CollisionRectangle, secondEntity.CollisionRectangle) {
+						world.hitBuffer = append(world.hitBuffer, handle)
+					}
+				}
+
+				var didGlobalCollisionHappen bool = false
+				world.collidingAttack = firstEntity.Handle
+				for _, handle := range world.hitBuffer {
+					secondEntity, ok := world.Get(handle)
+					// an attack hits each target once
+					if !ok || !recordHit(firstEntity, handle) {
+						continue
+					}
+					// a detonating attack deals its damage through the blast
+					if !firstEntity.detonateOnImpact {
+						world.applyDamage(firstEntity, secondEntity, firstEntity.Damage, firstEntity.DamageType)
+					}
+					if firstEntity.isProjectile {
+						world.projectileHit(firstEntity, secondEntity)
+						if !firstEntity.IsValid {
+							break
+						}
+						continue
+					}
+					didGlobalCollisionHappen = true
+				}
+				world.collidingAttack = EntityHandle{}
+
+				// a swing carries on through everything in its arc, blasting on
+				// every tick it hits something when it detonates
+				if didGlobalCollisionHappen && firstEntity.detonateOnImpact {
+					world.detonate(firstEntity, firstEntity.Position)
+				}
+			}
+
+		}
+	}
+
+	// :player death, killed by a hit before the input of the next tick can
+	// play a card, a heal doesn't bring the player back
+	if playerEntity.Health <= 0 {
+		world.killPlayer(playerEntity)
+	}
+
+	// :update :cards
+	{
+		world.updateHand(delta_t, mousePositionScreen)
+	}
+
+	// :interpolation spawned this tick
+	// nothing to interpolate from, and the grabbed card sticks to the mouse
+	{
+		for i := 0; i < MAX_ENTITY_COUNT; i++ {
+			var entity *Entity = &world.Entities[i]
+			if entity.IsValid && entity.spawnSerial > spawnSerialAtTickStart {
+				entity.PreviousPosition = entity.Position
+			}
+		}
+		for i := 0; i < MAX_HAND_COUNT; i++ {
+			var card *Entity = &world.Hand.Cards[i]
+			if card.IsValid && (card.spawnSerial > spawnSerialAtTickStart || card.Handle == world.GrabbedCard) {
+				card.PreviousPosition = card.Position
+			}
+		}
+	}
+}
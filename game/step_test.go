@@ -0,0 +1,32 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestStepMovesThePlayer(t *testing.T) {
+	var world *World = NewWorld(DefaultConfig())
+	var delta_t float32 = world.TickDuration()
+
+	playerEntity, _ := world.Get(world.Player)
+	var start Vector2 = playerEntity.Position
+	for i := 0; i < 60; i++ {
+		world.Step(delta_t, InputState{Axis: Vector2{X: 1}})
+	}
+	if moved := playerEntity.Position.X - start.X; moved < float32(playerEntity.Speed)/2 {
+		t.Fatalf("a second of moving right took the player from %v to %v", start, playerEntity.Position)
+	}
+	if world.Tick != 60 {
+		t.Fatalf("ran 60 steps, the world is at tick %d", world.Tick)
+	}
+}
+
+func TestStepSurvivesRandomInput(t *testing.T) {
+	var world *World = NewWorld(DefaultConfig())
+	var random *rand.Rand = rand.New(rand.NewSource(2))
+
+	for i := 0; i < 1200; i++ {
+		world.Step(world.TickDuration(), randomInput(world, random))
+	}
+}
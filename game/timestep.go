@@ -0,0 +1,79 @@
+package game
+
+func (world *World) TickDuration() float32 {
+	return 1.0 / world.Config.TickRate
+}
+
+// Advance feeds one rendered frame into the fixed timestep: frameTime is added
+// to the accumulator and as many Steps as fit are run. The returned alpha in
+// [0, 1) is how far the frame is between the previous and the current tick,
+// for RenderPosition and RenderCameraTarget.
+//
+// While a replay is playing its recorded input is used instead of in.
+//
+// Button edges seen in a frame that runs no tick are held until the next tick
+// so a quick click is never dropped, and they are only delivered to the first
+// tick of a frame that runs several.
+func (world *World) Advance(frameTime float32, in InputState) float32 {
+	var tickDuration float32 = world.TickDuration()
+	world.Events = world.Events[:0]
+
+	if frameTime > world.Config.MaxFrameTime {
+		frameTime = world.Config.MaxFrameTime
+	}
+	world.accumulator += frameTime
+	world.pendingInput = mergeInput(world.pendingInput, in)
+
+	for world.accumulator >= tickDuration {
+		var tickInput InputState = world.pendingInput
+		var replayFrame ReplayFrame
+		if world.Playback != nil {
+			var ok bool
+			if replayFrame, ok = world.Playback.next(); !ok {
+				// the replay is over, hold the world on its last tick
+				world.accumulator = 0
+				break
+			}
+			tickInput = replayFrame.Input
+		}
+
+		world.Step(tickDuration, tickInput)
+		world.accumulator -= tickDuration
+		world.pendingInput = clearInputEdges(world.pendingInput)
+
+		if world.Recorder != nil || world.Playback != nil {
+			var checksum uint64 = world.Checksum()
+			if world.Recorder != nil {
+				world.Recorder.record(ReplayFrame{Input: tickInput, Checksum: checksum})
+			}
+			if world.Playback != nil {
+				world.Playback.verify(replayFrame, world.Tick, checksum)
+			}
+		}
+	}
+
+	return world.accumulator / tickDuration
+}
+
+// mergeInput keeps the latest held state and every edge not consumed yet.
+func mergeInput(pending, in InputState) InputState {
+	in.MouseLeftPressed = in.MouseLeftPressed || pending.MouseLeftPressed
+	in.MouseLeftReleased = in.MouseLeftReleased || pending.MouseLeftReleased
+	in.MouseRightPressed = in.MouseRightPressed || pending.MouseRightPressed
+	return in
+}
+
+func clearInputEdges(in InputState) InputState {
+	in.MouseLeftPressed = false
+	in.MouseLeftReleased = false
+	in.MouseRightPressed = false
+	return in
+}
+
+func (en *Entity) RenderPosition(alpha float32) Vector2 {
+	return Vector2Lerp(en.PreviousPosition, en.Position, alpha)
+}
+
+func (world *World) RenderCameraTarget(alpha float32) Vector2 {
+	return Vector2Lerp(world.PreviousCameraTarget, world.CameraTarget, alpha)
+}
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// sameState compares what a run is made of, the tick, the generator and
+// every entity.
+func sameState(first, second *World) bool {
+	return first.Tick == second.Tick && first.Random == second.Random && first.Entities == second.Entities
+}
+
+func TestSameSeedAndInputSameState(t *testing.T) {
+	var config Config = DefaultConfig()
+	config.Seed = 3
+	var first *World = NewWorld(config)
+	var second *World = NewWorld(config)
+	var random *rand.Rand = rand.New(rand.NewSource(3))
+
+	for i := 0; i < 900; i++ {
+		var in InputState = randomInput(first, random)
+		// frames of uneven length, the ticks they run are the same
+		var frameTime float32 = first.TickDuration() * (0.5 + random.Float32())
+		first.Advance(frameTime, in)
+		second.Advance(frameTime, in)
+		if !sameState(first, second) {
+			t.Fatalf("the worlds diverged on frame %d, tick %d and %d", i, first.Tick, second.Tick)
+		}
+	}
+	if first.Tick == 0 {
+		t.Fatal("no tick ran")
+	}
+}
+
+func TestDifferentSeedDifferentState(t *testing.T) {
+	var config Config = DefaultConfig()
+	config.Seed = 4
+	var first *World = NewWorld(config)
+	config.Seed = 5
+	var second *World = NewWorld(config)
+
+	for i := 0; i < 600; i++ {
+		first.Step(first.TickDuration(), InputState{})
+		second.Step(second.TickDuration(), InputState{})
+	}
+	if sameState(first, second) {
+		t.Fatal("worlds of different seeds ended up the same")
+	}
+}
@@ -0,0 +1,299 @@
+package game
+
+import (
+	"maps"
+	"slices"
+)
+
+// Config holds the settings that used to be locals of main(): screen size for
+// the hand area and the enemy spawners.
+type Config struct {
+	ScreenWidth  int32
+	ScreenHeight int32
+
+	// see data/spawns.json
+	Spawners []Spawner
+
+	OverflowPolicy OverflowPolicy
+
+	// gameplay runs in fixed ticks of 1/TickRate seconds, frames longer than
+	// MaxFrameTime are clamped so a hitch can't queue up a burst of ticks
+	TickRate     float32
+	MaxFrameTime float32
+
+	// the whole run is reproduced by this seed and the inputs
+	Seed uint64
+
+	// by name, see data/archetypes.json
+	Archetypes map[string]Archetype
+
+	// by name, see data/statuses.json
+	Statuses map[string]Status
+
+	// by name, see data/cards.json
+	Cards map[string]Card
+	// most cards the hand holds, at most MAX_HAND_COUNT
+	HandSize     int32
+	StartingHand int32
+	// a card is drawn every DrawInterval seconds while the hand has room,
+	// 0 turns timed draws off, and DrawOnKill cards for every enemy killed
+	DrawInterval float32
+	DrawOnKill   int32
+
+	// who hurts whom, allies hurt each other only with FriendlyFire
+	Relations    Relations
+	FriendlyFire bool
+
+	// knockback velocity is multiplied by e^-VelocityDecay every second
+	VelocityDecay float32
+
+	// respawns the player gets before the run is over, and the seconds the
+	// world stands still for the death animation
+	Lives     int32
+	DeathTime float32
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ScreenWidth:  800,
+		ScreenHeight: 450,
+
+		Spawners: slices.Clone(defaultSpawners),
+
+		OverflowPolicy: OVERFLOW_REFUSE,
+
+		TickRate:     60,
+		MaxFrameTime: 0.25,
+
+		Seed: 1,
+
+		Archetypes: maps.Clone(defaultArchetypes),
+		Statuses:   maps.Clone(defaultStatuses),
+
+		Cards:        maps.Clone(defaultCards),
+		HandSize:     5,
+		StartingHand: 3,
+		DrawInterval: 3,
+		DrawOnKill:   1,
+
+		Relations: defaultRelations(),
+
+		VelocityDecay: 10,
+
+		DeathTime: 1.5,
+	}
+}
+
+type Hand struct {
+	Cards [MAX_HAND_COUNT]Entity
+
+	allocator slotAllocator
+}
+
+// EntityHandle refers to an entity slot in World.Entities (or Hand.Cards for
+// cards held in the hand). The generation is bumped every time the slot is
+// handed out again, so a handle kept across frames stops resolving once its
+// entity is destroyed instead of silently pointing at whatever reused the
+// slot. The zero handle never resolves.
+type EntityHandle struct {
+	Index      int32
+	Generation uint32
+}
+
+type WorldFrame struct {
+	SelectedEntity EntityHandle
+	// card in the hand under the mouse
+	HoveredCard EntityHandle
+}
+
+type World struct {
+	Entities [MAX_ENTITY_COUNT]Entity
+	// the cards the player can cast, the rest of the cycle is in Deck
+	Hand   Hand
+	Deck   Deck
+	Config Config
+
+	// per frame data, reset at the start of every Step
+	Frame WorldFrame
+
+	// number of Steps run so far
+	Tick uint64
+
+	// events of the ticks run by the last Advance, cleared when the next one
+	// starts. Callers of Step clear them themselves.
+	Events []Event
+
+	Random Random
+
+	// see :player death, Lives are the respawns left
+	Lives      int32
+	DeathTimer float32
+	GameOver   bool
+	Stats      RunStats
+
+	// GrabbedCard is a card of the Hand being dragged
+	Player               EntityHandle
+	GrabbedCard          EntityHandle
+	CameraTarget         Vector2
+	PreviousCameraTarget Vector2
+
+	allocator   slotAllocator
+	spawnSerial uint64
+
+	spatial     spatialHash
+	queryBuffer []EntityHandle
+	hitBuffer   []EntityHandle
+
+	// the attack :collision is running the hits of, the fragments it splits
+	// into must not recycle it from under it
+	collidingAttack EntityHandle
+
+	spawnTimers []float32
+
+	accumulator  float32
+	pendingInput InputState
+
+	// optional, every tick run by Advance is recorded to Recorder, and while
+	// Playback is set its input replaces the live one
+	Recorder       *ReplayRecorder
+	Playback       *ReplayPlayer
+	checksumBuffer []byte
+}
+
+func newEmptyWorld(config Config) *World {
+	var world *World = &World{Config: config}
+	world.Random = NewRandom(config.Seed)
+	world.allocator = newSlotAllocator(MAX_ENTITY_COUNT)
+	world.Hand.allocator = newSlotAllocator(MAX_HAND_COUNT)
+	world.spatial = newSpatialHash()
+	world.spawnTimers = make([]float32, len(config.Spawners))
+	return world
+}
+
+func NewWorld(config Config) *World {
+	var world *World = newEmptyWorld(config)
+
+	playerEntity, err := world.spawnArchetype("player", Vector2{X: 0, Y: 0})
+	assert(err == nil, "world not correctly initialized")
+	world.Player = playerEntity.Handle
+	world.Lives = config.Lives
+
+	var playerSprite *SpriteSize = getSpriteSize(playerEntity.SpriteId)
+	world.CameraTarget = Vector2{
+		X: playerEntity.Position.X + (float32(playerSprite.Width) / 2.0),
+		Y: playerEntity.Position.Y + (float32(playerSprite.Height) / 2.0),
+	}
+	world.PreviousCameraTarget = world.CameraTarget
+
+	world.newDeck()
+	world.drawCards(config.StartingHand)
+
+	return world
+}
+
+func (hand *Hand) createCard() (*Entity, error) {
+	handle, ok := hand.allocator.alloc()
+	if !ok {
+		hand.allocator.stats.Refused += 1
+		return nil, ErrHandFull
+	}
+
+	var entityFound *Entity = &hand.Cards[handle.Index]
+	entityFound.Handle = handle
+	entityFound.IsValid = true
+	return entityFound, nil
+}
+
+func (hand *Hand) destroyCard(en *Entity) {
+	if !en.IsValid {
+		return
+	}
+	hand.allocator.release(en.Handle.Index)
+	*en = Entity{}
+	en.IsValid = false
+}
+
+// Get resolves a handle into Cards, like World.Get.
+func (hand *Hand) Get(handle EntityHandle) (*Entity, bool) {
+	if handle.Index < 0 || handle.Index >= MAX_HAND_COUNT || handle.Generation == 0 {
+		return nil, false
+	}
+
+	var card *Entity = &hand.Cards[handle.Index]
+	if !card.IsValid || card.Handle != handle {
+		return nil, false
+	}
+	return card, true
+}
+
+func (hand *Hand) Stats() AllocatorStats {
+	return hand.allocator.stats
+}
+
+func (world *World) createEntity() (*Entity, error) {
+	handle, ok := world.allocator.alloc()
+	if !ok && world.Config.OverflowPolicy == OVERFLOW_RECYCLE_OLDEST_ATTACK {
+		if oldest, found := world.oldestAttack(); found {
+			world.destroyEntity(oldest)
+			world.allocator.stats.Recycled += 1
+			handle, ok = world.allocator.alloc()
+		}
+	}
+	if !ok {
+		world.allocator.stats.Refused += 1
+		return nil, ErrWorldFull
+	}
+
+	var entityFound *Entity = &world.Entities[handle.Index]
+	entityFound.Handle = handle
+	entityFound.IsValid = true
+
+	world.spawnSerial += 1
+	entityFound.spawnSerial = world.spawnSerial
+
+	entityFound.inputAxis = Vector2{X: 0, Y: 0}
+	return entityFound, nil
+}
+
+// oldestAttack is only searched when the world is full, so the linear scan
+// stays off the common spawn path. The attack :collision is working on is
+// never picked.
+func (world *World) oldestAttack() (*Entity, bool) {
+	var oldest *Entity = nil
+	for i := 0; i < MAX_ENTITY_COUNT; i++ {
+		var entity *Entity = &world.Entities[i]
+		if entity.IsValid && entity.Type == ARCH_ATTACK && entity.Handle != world.collidingAttack {
+			if oldest == nil || entity.spawnSerial < oldest.spawnSerial {
+				oldest = entity
+			}
+		}
+	}
+	return oldest, oldest != nil
+}
+
+func (world *World) destroyEntity(en *Entity) {
+	if !en.IsValid {
+		return
+	}
+	world.allocator.release(en.Handle.Index)
+	*en = Entity{}
+	en.IsValid = false
+}
+
+func (world *World) EntityStats() AllocatorStats {
+	return world.allocator.stats
+}
+
+// Get resolves a handle, failing when the entity it referred to was destroyed
+// or its slot has since been reused.
+func (world *World) Get(handle EntityHandle) (*Entity, bool) {
+	if handle.Index < 0 || handle.Index >= MAX_ENTITY_COUNT || handle.Generation == 0 {
+		return nil, false
+	}
+
+	var entity *Entity = &world.Entities[handle.Index]
+	if !entity.IsValid || entity.Handle != handle {
+		return nil, false
+	}
+	return entity, true
+}
@@ -0,0 +1,58 @@
+package game
+
+import "testing"
+
+func TestHandleStopsResolvingOnceDestroyed(t *testing.T) {
+	var world *World = NewWorld(DefaultConfig())
+	goblin, err := world.spawnArchetype("goblin", Vector2{X: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var handle EntityHandle = goblin.Handle
+
+	if entity, ok := world.Get(handle); !ok || entity != goblin {
+		t.Fatal("a live entity doesn't resolve")
+	}
+	world.destroyEntity(goblin)
+	if _, ok := world.Get(handle); ok {
+		t.Fatal("a destroyed entity still resolves")
+	}
+
+	troll, err := world.spawnArchetype("troll", Vector2{X: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if troll != goblin {
+		t.Fatal("the freed slot wasn't reused, the test proves nothing")
+	}
+	if _, ok := world.Get(handle); ok {
+		t.Fatal("the handle of the goblin resolves to the troll that reused its slot")
+	}
+	if _, ok := world.Get(EntityHandle{}); ok {
+		t.Fatal("the zero handle resolves")
+	}
+}
+
+// a card played while held used to leave the grab pointing at its slot,
+// dragging whatever was drawn there next around with the mouse
+func TestGrabDoesNotFollowAReusedSlot(t *testing.T) {
+	world, _ := testWorld()
+	card, ok := world.Hand.Get(world.Hand.Cards[0].Handle)
+	if !ok {
+		t.Fatal("the starting hand is empty")
+	}
+	world.GrabbedCard = card.Handle
+	var grabbed EntityHandle = card.Handle
+
+	world.discardCard(card)
+	world.drawCards(1)
+	if !card.IsValid || card.Handle == grabbed {
+		t.Fatal("the freed hand slot wasn't reused, the test proves nothing")
+	}
+
+	var mouse Vector2 = Vector2{X: -300, Y: -300}
+	world.Step(world.TickDuration(), InputState{MouseScreen: mouse, MouseWorld: mouse, MouseLeftDown: true})
+	if card.Position == mouse {
+		t.Fatal("the card drawn into the slot of the grabbed card followed the mouse")
+	}
+}
@@ -1,108 +1,53 @@
 package main
 
 import (
+	"duelingMonsters/data"
+	"duelingMonsters/game"
+	"flag"
 	"fmt"
-	"math"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
-// :globals constants
-const (
-	MAX_ENTITY_COUNT               = 1024
-	MAX_HAND_COUNT                 = 40
-	entitySelectionRadius  float32 = 10.0
-	PLAYER_HEALTH                  = 100
-	TROLL_HEALTH                   = 10
-	GOBLIN_HEALTH                  = 10
-	PLAYER_MOVEMENT_RADIUS float32 = 1
-)
-
-// :enum EntityArchType
-type EntityArchType int
-
-const (
-	ARCH_NIL           EntityArchType = 0
-	ARCH_TROLL         EntityArchType = 1
-	ARCH_GOBLIN        EntityArchType = 2
-	ARCH_PLAYER        EntityArchType = 3
-	ARCH_CARD_FIREBALL EntityArchType = 4
-	ARCH_CARD          EntityArchType = 5
-	ARCH_ATTACK        EntityArchType = 6
-)
-
 type Sprite struct {
 	Image rl.Texture2D
 }
 
-// :enum SpriteId
-type SpriteId int
-
-const (
-	SPRITE_NIL SpriteId = iota
-	SPRITE_PLAYER
-	SPRITE_GOBLIN
-	SPRITE_TROLL
-	SPRITE_CARD_FIREBALL
-	SPRITE_ATTACK_FIREBALL
-	SPRITE_ATTACK_BASIC
-	SPRITE_ATTACK_SWORD
-	SPRITE_MAX
-)
-
-type Entity struct {
-	Position           rl.Vector2
-	IsValid            bool
-	Type               EntityArchType
-	SpriteId           SpriteId
-	Health             int32
-	inputAxis          rl.Vector2
-	CollisionRectangle rl.Rectangle
-
-	// for cards
-	Range  int32
-	Width  int32
-	Damage int32
-	Speed  int32
-
-	// for attacks
-	MaxPosition    rl.Vector2
-	CenterPosition rl.Vector2
-	Angle          float32
-	MaxAngle       float32
-	isMelee        bool
-	Radius         float32
-	isProjectile   bool
+// Flash is a short lived circle drawn where something exploded.
+type Flash struct {
+	Position rl.Vector2
+	Radius   float32
+	Time     float32
 }
 
-type Card struct {
-	base Entity
-}
+const flashDuration float32 = 0.25
 
-type Hand struct {
-	Cards [MAX_HAND_COUNT]Entity
+// DamageNumber floats up from where a hit landed
+type DamageNumber struct {
+	Position rl.Vector2
+	Text     string
+	Color    rl.Color
+	Time     float32
 }
 
-type World struct {
-	Entities [MAX_ENTITY_COUNT]Entity
-}
+const damageNumberDuration float32 = 0.6
 
-type WorldFrame struct {
-	SelectedEntity *Entity
+var damageTypeColors = [game.DAMAGE_TYPE_COUNT]rl.Color{
+	game.DAMAGE_PHYSICAL: rl.White,
+	game.DAMAGE_FIRE:     rl.Orange,
+	game.DAMAGE_ICE:      rl.SkyBlue,
+	game.DAMAGE_POISON:   rl.Lime,
 }
 
 // :globals structs
-var worldFrame WorldFrame
-var world *World = nil
-var hand *Hand = nil
-var sprites [SPRITE_MAX]Sprite
+// indexed by game.SpriteId
+var sprites []Sprite
 
 // :helpers :engine functions
-func IsInRange(entity1 *Entity, entity2 *Entity) bool {
-	distance := rl.Vector2Distance(entity1.Position, entity2.Position)
-	return distance <= float32(entity1.Range)
-}
-
 func boolToInt(x bool) int32 {
 	if x {
 		return 1
@@ -111,278 +56,190 @@ func boolToInt(x bool) int32 {
 	}
 }
 
-func assert(condition bool, error string) {
-	if condition == false {
-		fmt.Print(error)
-		panic("")
+// :helper game functions
+func getSprite(id game.SpriteId) *Sprite {
+	if id > game.SPRITE_NIL && int(id) < len(sprites) {
+		return &sprites[id]
 	}
+	return &Sprite{}
 }
 
-func almostEquals(a, b, epsilon float32) bool {
-	return float32(math.Abs(float64(a-b))) <= epsilon
+// loadSprites loads every sprite registered with the game that has no texture
+// yet, the built in ones and any image path the archetype data brought in.
+func loadSprites() {
+	if len(sprites) == 0 {
+		sprites = append(sprites, Sprite{})
+	}
+	for id := game.SpriteId(len(sprites)); id < game.SpriteCount(); id++ {
+		sprites = append(sprites, Sprite{Image: rl.LoadTexture(game.SpritePath(id))})
+		game.SetSpriteSize(id, sprites[id].Image.Width, sprites[id].Image.Height)
+	}
 }
 
-func animateF32ToTarget(value *float32, target, delta_t, rate float32) bool {
-	*value += (target - *value) * (1.0 - float32(math.Pow(2.0, float64(-rate*delta_t))))
-	if almostEquals(*value, target, 0.001) {
-		*value = target
-		return true
+// drawCard draws a card of the hand at its screen position, scaled and tilted
+// as the hand laid it out, with its cost and name on top.
+func drawCard(world *game.World, card *game.Entity, alpha float32) {
+	var sprite *Sprite = getSprite(card.SpriteId)
+	var position rl.Vector2 = rl.Vector2(card.RenderPosition(alpha))
+	var width float32 = float32(sprite.Image.Width) * game.HAND_CARD_SCALE * card.CardScale
+	var height float32 = float32(sprite.Image.Height) * game.HAND_CARD_SCALE * card.CardScale
+
+	var cardColor rl.Color = rl.White
+	var cost int32 = world.Config.Cards[card.Card].Cost
+	if playerEntity, ok := world.Get(world.Player); ok && !world.CanAfford(card, playerEntity) {
+		cardColor = rl.Gray
 	}
 
-	return false
-}
+	var source rl.Rectangle = rl.Rectangle{X: 0, Y: 0, Width: float32(sprite.Image.Width), Height: float32(sprite.Image.Height)}
+	var destination rl.Rectangle = rl.Rectangle{X: position.X, Y: position.Y, Width: width, Height: height}
+	rl.DrawTexturePro(sprite.Image, source, destination, rl.Vector2{X: width / 2, Y: height / 2}, card.Angle, cardColor)
 
-func animateV2ToTarget(value *rl.Vector2, target rl.Vector2, delta_t, rate float32) {
-	animateF32ToTarget(&value.X, target.X, delta_t, rate)
-	animateF32ToTarget(&value.Y, target.Y, delta_t, rate)
-}
-
-const tileWidth int32 = 8
+	var left int32 = int32(position.X - width/2)
+	var top int32 = int32(position.Y - height/2)
+	rl.DrawCircle(left+4, top+4, 8, rl.DarkBlue)
+	rl.DrawText(fmt.Sprint(cost), left+1, top-1, 10, rl.White)
 
-func worldPositionToTilePosition(worldPosition float32) float32 {
-	return float32(math.Round(float64(worldPosition) / float64(tileWidth)))
+	var fontSize int32 = 10
+	if rl.MeasureText(card.Card, fontSize) > int32(width) {
+		fontSize = 8
+	}
+	var nameWidth int32 = rl.MeasureText(card.Card, fontSize)
+	rl.DrawText(card.Card, int32(position.X)-nameWidth/2, int32(position.Y+height/2)-fontSize-2, fontSize, rl.Black)
 }
 
-func tilePositionToWorldPosition(tilePosition float32) float32 {
-	return float32(tilePosition) * float32(tileWidth)
+// drawStatusIcon draws a status as a size square of its color with its icon
+// letter on it.
+func drawStatusIcon(status game.Status, x int32, y int32, size int32) {
+	var color rl.Color = rl.Color{R: status.Color[0], G: status.Color[1], B: status.Color[2], A: 255}
+	rl.DrawRectangle(x, y, size, size, color)
+	rl.DrawText(status.Icon, x+1, y, size, rl.Black)
 }
 
-func roundV2ToTile(worldPosition rl.Vector2) rl.Vector2 {
-	worldPosition.X = tilePositionToWorldPosition(worldPositionToTilePosition(worldPosition.X))
-	worldPosition.Y = tilePositionToWorldPosition(worldPositionToTilePosition(worldPosition.Y))
-	return worldPosition
-}
+func readInput(camera rl.Camera2D) game.InputState {
+	var in game.InputState
 
-// :helper game functions
-func getSprite(id SpriteId) *Sprite {
-	if id >= 0 && id < SPRITE_MAX {
-		return &sprites[id]
+	in.Running = rl.IsKeyDown(rl.KeyLeftShift)
+	if rl.IsKeyDown(rl.KeyS) {
+		in.Axis.Y += 1
 	}
-	return &sprites[0]
-}
-
-func createCardInHand() *Entity {
-	var entityFound *Entity = nil
-	for i := 0; i < MAX_HAND_COUNT; i++ {
-		var existingEntity *Entity = &(hand.Cards[i])
-		if !existingEntity.IsValid {
-			entityFound = existingEntity
-			break
-
-		}
+	if rl.IsKeyDown(rl.KeyW) {
+		in.Axis.Y -= 1
 	}
-	// :TODO assert here
-	assert(entityFound != nil, "max # of cards in hand reached")
-	entityFound.IsValid = true
-	return entityFound
-}
-
-func createEntity() *Entity {
-	var entityFound *Entity = nil
-	for i := 0; i < MAX_ENTITY_COUNT; i++ {
-
-		var existingEntity *Entity = &world.Entities[i]
-		if !existingEntity.IsValid {
-
-			entityFound = existingEntity
-			break
-		}
+	if rl.IsKeyDown(rl.KeyD) {
+		in.Axis.X += 1
+	}
+	if rl.IsKeyDown(rl.KeyA) {
+		in.Axis.X -= 1
 	}
 
-	assert(entityFound != nil, "max # of entities reached")
-	entityFound.IsValid = true
+	var mousePositionScreen rl.Vector2 = rl.GetMousePosition()
+	in.MouseScreen = game.Vector2(mousePositionScreen)
+	in.MouseWorld = game.Vector2(rl.GetScreenToWorld2D(mousePositionScreen, camera))
 
-	entityFound.inputAxis = rl.Vector2{X: 0, Y: 0}
-	return entityFound
-}
+	in.MouseLeftDown = rl.IsMouseButtonDown(rl.MouseButtonLeft)
+	in.MouseLeftPressed = rl.IsMouseButtonPressed(rl.MouseButtonLeft)
+	in.MouseLeftReleased = rl.IsMouseButtonReleased(rl.MouseButtonLeft)
+	in.MouseRightPressed = rl.IsMouseButtonPressed(rl.MouseButtonRight)
 
-func destroyEntity(en *Entity) {
-	*en = Entity{}
-	en.IsValid = false
+	return in
 }
 
-func setupTroll(en *Entity, position *rl.Vector2) {
-	en.Type = ARCH_TROLL
-	en.SpriteId = SPRITE_TROLL
-	en.Health = TROLL_HEALTH
-	en.Damage = 30
-	en.Speed = 50
-	en.Range = 100
-
-	if position != nil {
-		en.Position = *position
-	} else {
-		en.Position = rl.Vector2{X: 0, Y: 0}
+func main() {
 
-	}
+	var seed = flag.Uint64("seed", 0, "seed for the run, 0 picks one from the clock")
+	var recordPath = flag.String("record", "last_run.replay", "file the run's input is recorded to, empty disables recording")
+	var replayPath = flag.String("replay", "", "replay file to play back instead of reading input")
+	var savesDir = flag.String("saves", "saves", "directory the save slots are kept in")
+	var saveJson = flag.Bool("save-json", false, "write saves as JSON instead of binary")
+	var dataDir = flag.String("data", "data", "directory of the gameplay data files")
+	var reloadLive = flag.Bool("reload-live", true, "hot reloaded archetype stats also apply to entities already in the world")
+	var lives = flag.Int("lives", 0, "times the player respawns before the run is over")
+	var friendlyFire = flag.Bool("friendly-fire", false, "attacks and effects also hurt allies of their owner")
+	var recycleAttacks = flag.Bool("recycle-attacks", false, "a full world recycles its oldest attack instead of refusing to spawn")
+	var debugAI = flag.Bool("debug-ai", false, "start with the enemy ai overlay on, F4 toggles it, transitions are logged while it's on")
+	flag.Parse()
 
-	var sprite *Sprite = getSprite(en.SpriteId)
-	en.CollisionRectangle.X = (en.Position.X - float32(sprite.Image.Width)/2)
-	en.CollisionRectangle.Y = en.Position.Y - float32(sprite.Image.Height/2)
-	en.CollisionRectangle.Width = float32(sprite.Image.Width)
-	en.CollisionRectangle.Height = float32(sprite.Image.Height)
-}
+	rl.SetConfigFlags(rl.FlagVsyncHint | rl.FlagWindowHighdpi)
 
-func setupPlayer(en *Entity, position *rl.Vector2) {
-	en.Type = ARCH_PLAYER
-	en.SpriteId = SPRITE_PLAYER
-	en.Health = PLAYER_HEALTH
-	en.Speed = 100
+	var config game.Config = game.DefaultConfig()
+	config.Seed = *seed
+	config.FriendlyFire = *friendlyFire
+	config.Lives = int32(*lives)
+	if *recycleAttacks {
+		config.OverflowPolicy = game.OVERFLOW_RECYCLE_OLDEST_ATTACK
+	}
+	if config.Seed == 0 {
+		config.Seed = uint64(time.Now().UnixNano())
+	}
 
-	if position != nil {
-		en.Position = *position
+	// :data the files on disk win over the defaults built into the game. A
+	// broken file is reported on screen and the defaults are used until it is
+	// fixed, the game never stops over a typo.
+	var archetypesPath string = filepath.Join(*dataDir, data.ArchetypesFile)
+	var spawnsPath string = filepath.Join(*dataDir, data.SpawnsFile)
+	var cardsPath string = filepath.Join(*dataDir, data.CardsFile)
+	var statusesPath string = filepath.Join(*dataDir, data.StatusesFile)
+	var dataErrors map[string]error = make(map[string]error)
+
+	if archetypes, err := game.LoadArchetypes(archetypesPath); err != nil {
+		dataErrors[archetypesPath] = err
 	} else {
-		en.Position = rl.Vector2{X: 0, Y: 0}
-
+		config.Archetypes = archetypes
 	}
-
-	var sprite *Sprite = getSprite(en.SpriteId)
-	en.CollisionRectangle.X = (en.Position.X - float32(sprite.Image.Width)/2)
-	en.CollisionRectangle.Y = en.Position.Y - float32(sprite.Image.Height/2)
-	en.CollisionRectangle.Width = float32(sprite.Image.Width)
-	en.CollisionRectangle.Height = float32(sprite.Image.Height)
-}
-
-func setupGoblin(en *Entity, position *rl.Vector2) {
-	en.Type = ARCH_GOBLIN
-	en.SpriteId = SPRITE_GOBLIN
-	en.Health = GOBLIN_HEALTH
-	en.Damage = 10
-	en.Speed = 50
-	en.Range = 100
-
-	if position != nil {
-		en.Position = *position
+	if spawners, err := game.LoadSpawners(spawnsPath, config.Archetypes); err != nil {
+		dataErrors[spawnsPath] = err
 	} else {
-		en.Position = rl.Vector2{X: 0, Y: 0}
-
+		config.Spawners = spawners
+	}
+	if statuses, err := game.LoadStatuses(statusesPath); err != nil {
+		dataErrors[statusesPath] = err
+	} else {
+		config.Statuses = statuses
+	}
+	if cards, err := game.LoadCards(cardsPath, config.Archetypes, config.Statuses); err != nil {
+		dataErrors[cardsPath] = err
+	} else {
+		config.Cards = cards
 	}
 
-	var sprite *Sprite = getSprite(en.SpriteId)
-	en.CollisionRectangle.X = (en.Position.X - float32(sprite.Image.Width)/2)
-	en.CollisionRectangle.Y = en.Position.Y - float32(sprite.Image.Height/2)
-	en.CollisionRectangle.Width = float32(sprite.Image.Width)
-	en.CollisionRectangle.Height = float32(sprite.Image.Height)
-}
-
-func setupCardFireball(en *Entity) {
-	en.Type = ARCH_CARD
-	en.SpriteId = SPRITE_CARD_FIREBALL
-	en.Range = 100
-	en.Width = 5
-	en.Damage = 2
-	en.Health = 1
-}
-
-func setupAttackFireball(en *Entity) {
-	en.Type = ARCH_ATTACK
-	en.SpriteId = SPRITE_ATTACK_FIREBALL
-	en.Damage = 4
-	en.Speed = 200
-	en.Health = 1
-	en.Range = 100
-	en.isProjectile = true
-
-	var sprite *Sprite = getSprite(en.SpriteId)
-	en.CollisionRectangle.X = (en.Position.X - float32(sprite.Image.Width)/2)
-	en.CollisionRectangle.Y = en.Position.Y - float32(sprite.Image.Height/2)
-	en.CollisionRectangle.Width = float32(sprite.Image.Width)
-	en.CollisionRectangle.Height = float32(sprite.Image.Height)
-}
-
-func setupAttackBasic(en *Entity) {
-	en.Type = ARCH_ATTACK
-	en.SpriteId = SPRITE_ATTACK_BASIC
-	en.Damage = 1
-	en.Speed = 150
-	en.Health = 1
-	en.Range = 85
-	en.isProjectile = true
-
-	var sprite *Sprite = getSprite(en.SpriteId)
-	en.CollisionRectangle.X = (en.Position.X - float32(sprite.Image.Width)/2)
-	en.CollisionRectangle.Y = en.Position.Y - float32(sprite.Image.Height/2)
-	en.CollisionRectangle.Width = float32(sprite.Image.Width)
-	en.CollisionRectangle.Height = float32(sprite.Image.Height)
-}
-
-func setupAttackSword(en *Entity, position, maxPosition rl.Vector2) {
-	en.Type = ARCH_ATTACK
-	en.SpriteId = SPRITE_ATTACK_SWORD
-	en.Damage = 3
-	en.Speed = 40
-	en.Health = 1
-	en.Range = 30
-	en.isMelee = true
-	en.MaxPosition = maxPosition
-	en.Angle = 0
-	en.MaxAngle = 90
-
-	var sprite *Sprite = getSprite(en.SpriteId)
-	en.CollisionRectangle.X = (en.Position.X - float32(sprite.Image.Width)/2)
-	en.CollisionRectangle.Y = en.Position.Y - float32(sprite.Image.Height/2)
-	en.CollisionRectangle.Width = float32(sprite.Image.Width)
-	en.CollisionRectangle.Height = float32(sprite.Image.Height)
-
-}
-
-func main() {
+	// :replay a playback runs with the recorded config so it sees the same seed
+	var replay *game.Replay = nil
+	if *replayPath != "" {
+		file, err := os.Open(*replayPath)
+		if err != nil {
+			log.Fatalf("opening replay: %v", err)
+		}
+		replay, err = game.LoadReplay(file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("loading replay %s: %v", *replayPath, err)
+		}
+		config = replay.Config
+	}
+	var screenWidth int32 = config.ScreenWidth
+	var screenHeight int32 = config.ScreenHeight
 
-	rl.SetConfigFlags(rl.FlagVsyncHint | rl.FlagWindowHighdpi)
+	rl.InitWindow(screenWidth, screenHeight, "Dueling Monsters")
 
-	const screenWidth int32 = 800
-	const screenHeight int32 = 450
+	loadSprites()
 
-	var spawnTrollRate float32 = 4
-	var spawnGlobinRate float32 = 2
-	var spawnTrollPosition rl.Vector2 = rl.Vector2{X: 30, Y: 40}
-	var spawnGoblinPosition rl.Vector2 = rl.Vector2{X: 20, Y: 20}
-	var spawnTrollAmount int32 = 1
-	var spawnGoblinAmount int32 = 2
-	//var maxEnemyToSpawn int32 = 12
-	var elapsedTimeGoblin float32 = 0
-	var elapsedTimeTroll float32 = 0
+	//setup world, sprite sizes have to be loaded first for the collision rectangles
+	var world *game.World = game.NewWorld(config)
 
-	rl.InitWindow(screenWidth, screenHeight, "Dueling Monsters")
+	if replay != nil {
+		world.Playback = game.NewReplayPlayer(replay)
+	} else if *recordPath != "" {
+		file, err := os.Create(*recordPath)
+		if err != nil {
+			log.Fatalf("creating replay: %v", err)
+		}
+		defer file.Close()
 
-	//setup globals
-
-	world = &World{}
-	assert(world != nil, "world not correctly initialized")
-	hand = &Hand{}
-	assert(hand != nil, "hand not correctly initialized")
-
-	// initalze t
-	var cardFireballTest *Entity = createEntity()
-	setupCardFireball(cardFireballTest)
-
-	sprites[SPRITE_PLAYER] = Sprite{Image: rl.LoadTexture("./resources/player.png")}
-	sprites[SPRITE_GOBLIN] = Sprite{Image: rl.LoadTexture("./resources/goblin.png")}
-	sprites[SPRITE_TROLL] = Sprite{Image: rl.LoadTexture("./resources/troll.png")}
-	sprites[SPRITE_CARD_FIREBALL] =
-		Sprite{Image: rl.LoadTexture("./resources/card_fireball.png")}
-	sprites[SPRITE_ATTACK_FIREBALL] =
-		Sprite{Image: rl.LoadTexture("./resources/attack_fireball.png")}
-	sprites[SPRITE_ATTACK_BASIC] =
-		Sprite{Image: rl.LoadTexture("./resources/basic_attack.png")}
-	sprites[SPRITE_ATTACK_SWORD] =
-		Sprite{Image: rl.LoadTexture("./resources/sword.png")}
-
-	/* for i := 0; i < 2; i++ { */
-	/* 	var en *Entity = createEntity() */
-	/* 	setupTroll(en) */
-	/* 	var en2 *Entity = createEntity() */
-	/* 	setupGoblin(en2) */
-	/* 	en.Position = rl.Vector2{X: float32(rl.GetRandomValue(0, 200)), Y: float32(rl.GetRandomValue(0, 200))} */
-	/* 	en.Position = roundV2ToTile(en.Position) */
-	/**/
-	/* 	en2.Position = rl.Vector2{X: float32(rl.GetRandomValue(0, 200)), Y: float32(rl.GetRandomValue(0, 200))} */
-	/* 	en2.Position = roundV2ToTile(en2.Position) */
-	/* } */
-
-	var playerEntity *Entity = createEntity()
-
-	setupPlayer(playerEntity, &rl.Vector2{X: 0, Y: 0})
+		world.Recorder, err = game.NewReplayRecorder(file, config)
+		if err != nil {
+			log.Fatalf("recording replay: %v", err)
+		}
+	}
 
 	// :camera initialze
 
@@ -391,82 +248,221 @@ func main() {
 	camera.Zoom = 3.0
 	camera.Offset = rl.Vector2{X: float32(float32(screenWidth) / 2.0), Y: float32(float32(screenHeight) / 2.0)}
 	camera.Rotation = 0
-	camera.Target = rl.Vector2{
-		X: playerEntity.Position.X + (float32(sprites[SPRITE_PLAYER].Image.Width) / 2.0),
-		Y: playerEntity.Position.Y + (float32(sprites[SPRITE_PLAYER].Image.Height) / 2.0),
+	camera.Target = rl.Vector2(world.CameraTarget)
+
+	// :save slots
+	var saveSlots game.SaveSlots = game.SaveSlots{Dir: *savesDir, Format: game.SAVE_FORMAT_BINARY}
+	if *saveJson {
+		saveSlots.Format = game.SAVE_FORMAT_JSON
+	}
+	var selectedSlot int = 1
+	const autosaveInterval float32 = 60
+	var lastAutosaveTick uint64 = world.Tick
+
+	// :notice one line of feedback shown for a few seconds
+	var notice string = ""
+	var noticeTime float32 = 0
+	showNotice := func(text string) {
+		notice = text
+		noticeTime = 3
 	}
 
-	// :input movement variables
+	// :hud the mana bar flashes when a cast is refused
+	var manaFlashTime float32 = 0
+	var flashes []Flash = nil
+	var damageNumbers []DamageNumber = nil
+	var showAI bool = *debugAI
+
+	// :hot reload
+	var dataWatcher *game.DataWatcher = game.NewDataWatcher(archetypesPath, spawnsPath, cardsPath, statusesPath)
+	const dataPollInterval float32 = 0.5
+	var dataPollTime float32 = 0
+
+	var reloadData func(path string)
+	reloadData = func(path string) {
+		var reloaded string = ""
+		switch path {
+		case archetypesPath:
+			archetypes, err := game.LoadArchetypes(path)
+			if err == nil {
+				var updated int
+				updated, err = world.ReloadArchetypes(archetypes, *reloadLive)
+				reloaded = fmt.Sprintf("reloaded %s, %d live entities updated", path, updated)
+			}
+			if err != nil {
+				dataErrors[path] = err
+				return
+			}
+			loadSprites()
+		case spawnsPath:
+			spawners, err := game.LoadSpawners(path, world.Config.Archetypes)
+			if err != nil {
+				dataErrors[path] = err
+				return
+			}
+			world.ReloadSpawners(spawners)
+			reloaded = fmt.Sprintf("reloaded %s", path)
+		case cardsPath:
+			cards, err := game.LoadCards(path, world.Config.Archetypes, world.Config.Statuses)
+			if err == nil {
+				err = world.ReloadCards(cards)
+			}
+			if err != nil {
+				dataErrors[path] = err
+				return
+			}
+			reloaded = fmt.Sprintf("reloaded %s", path)
+		case statusesPath:
+			statuses, err := game.LoadStatuses(path)
+			if err == nil {
+				err = world.ReloadStatuses(statuses)
+			}
+			if err != nil {
+				dataErrors[path] = err
+				return
+			}
+			reloaded = fmt.Sprintf("reloaded %s", path)
+		}
+		delete(dataErrors, path)
+
+		// a file refused because of this one may load now
+		for other := range dataErrors {
+			reloadData(other)
+		}
+
+		// the recording was made with the config it started with
+		if world.Recorder != nil {
+			world.Recorder.Close()
+			world.Recorder = nil
+			reloaded += ", replay recording stopped"
+		}
+		showNotice(reloaded)
+	}
 
 	/// remove bellow
 	rl.SetTargetFPS(60)
 
 	defer rl.CloseWindow()
 
-	var runningMultiplier float32 = 1
-
-	// grabbed entity
-	var grabbedEntity *Entity = nil
-
 	for !rl.WindowShouldClose() {
-		// :clean :reset
-
-		worldFrame = WorldFrame{}
-		var delta_t float32 = rl.GetFrameTime()
-		elapsedTimeGoblin += delta_t
-		elapsedTimeTroll += delta_t
+		var frameTime float32 = rl.GetFrameTime()
 
 		// :input
-		{
-			playerEntity.inputAxis = rl.Vector2{X: 0, Y: 0}
-			if rl.IsKeyDown(rl.KeyLeftShift) {
-				runningMultiplier = 1.5
-			} else {
-				runningMultiplier = 1
+		var in game.InputState = readInput(camera)
+
+		// :save :load hotkeys, not while a replay plays back
+		if world.Playback == nil {
+			for slot, key := range []int32{rl.KeyF1, rl.KeyF2, rl.KeyF3} {
+				if rl.IsKeyPressed(key) {
+					selectedSlot = slot + 1
+					showNotice(fmt.Sprintf("save slot %d", selectedSlot))
+				}
 			}
 
-			if rl.IsKeyDown(rl.KeyS) {
-				playerEntity.inputAxis.Y += 1
+			if rl.IsKeyPressed(rl.KeyF5) {
+				if err := saveSlots.Store(selectedSlot, world); err != nil {
+					showNotice(fmt.Sprintf("save failed: %v", err))
+				} else {
+					showNotice(fmt.Sprintf("saved to slot %d", selectedSlot))
+				}
 			}
-			if rl.IsKeyDown(rl.KeyW) {
-				playerEntity.inputAxis.Y -= 1
+
+			var loadSlot int = -1
+			if rl.IsKeyPressed(rl.KeyF9) {
+				loadSlot = selectedSlot
+			} else if rl.IsKeyPressed(rl.KeyF10) {
+				loadSlot = game.AUTOSAVE_SLOT
 			}
-			if rl.IsKeyDown(rl.KeyD) {
-				playerEntity.inputAxis.X += 1
+			if loadSlot >= 0 {
+				loaded, err := saveSlots.Load(loadSlot)
+				if err != nil {
+					showNotice(fmt.Sprintf("load failed: %v", err))
+				} else {
+					// the recording can't be replayed across a load
+					if world.Recorder != nil {
+						world.Recorder.Close()
+						showNotice(fmt.Sprintf("loaded slot %d, replay recording stopped", loadSlot))
+					} else {
+						showNotice(fmt.Sprintf("loaded slot %d", loadSlot))
+					}
+					world = loaded
+					lastAutosaveTick = world.Tick
+				}
 			}
-			if rl.IsKeyDown(rl.KeyA) {
-				playerEntity.inputAxis.X -= 1
+		}
+
+		if rl.IsKeyPressed(rl.KeyF4) {
+			showAI = !showAI
+		}
+
+		// :hot reload, not while a replay plays back
+		dataPollTime -= frameTime
+		if world.Playback == nil && dataPollTime <= 0 {
+			dataPollTime = dataPollInterval
+			for _, path := range dataWatcher.Changed() {
+				reloadData(path)
 			}
+		}
 
+		// :restart a new run with the config of this one, data reloads and all
+		if world.GameOver && world.Playback == nil && rl.IsKeyPressed(rl.KeyR) {
+			var restartConfig game.Config = world.Config
+			if *seed == 0 {
+				restartConfig.Seed = uint64(time.Now().UnixNano())
+			}
+			if world.Recorder != nil {
+				world.Recorder.Close()
+				showNotice("new run, replay recording stopped")
+			}
+			world = game.NewWorld(restartConfig)
+			lastAutosaveTick = world.Tick
+			flashes = flashes[:0]
+			damageNumbers = damageNumbers[:0]
 		}
-		// :spawn Enemies
-		{
-			if elapsedTimeGoblin >= spawnGlobinRate {
-				for i := int32(0); i < spawnGoblinAmount; i++ {
-					var goblinEntity *Entity = createEntity()
-					var goblinPosition = (rl.Vector2AddValue(spawnGoblinPosition, float32(i*20)))
-					setupGoblin(goblinEntity, &goblinPosition)
+
+		// :simulate
+		var alpha float32 = world.Advance(frameTime, in)
+
+		// :events
+		for _, event := range world.Events {
+			switch event.Type {
+			case game.EVENT_CAST_REFUSED:
+				if event.Status != "" {
+					showNotice(fmt.Sprintf("can't cast %s under %s", event.Card, event.Status))
+					break
+				}
+				showNotice(fmt.Sprintf("not enough mana for %s", event.Card))
+				manaFlashTime = 0.4
+			case game.EVENT_EXPLOSION:
+				flashes = append(flashes, Flash{Position: rl.Vector2(event.Position), Radius: event.Radius, Time: flashDuration})
+			case game.EVENT_DAMAGE:
+				var number DamageNumber = DamageNumber{Position: rl.Vector2(event.Position), Text: fmt.Sprint(event.Damage.Amount), Color: damageTypeColors[event.Damage.Type], Time: damageNumberDuration}
+				if event.Damage.Critical {
+					number.Text += "!"
+					number.Color = rl.Gold
+				}
+				damageNumbers = append(damageNumbers, number)
+			case game.EVENT_PLAYER_RESPAWNED:
+				showNotice(fmt.Sprintf("respawned, %d lives left", world.Lives))
+			case game.EVENT_AI_TRANSITION:
+				if showAI {
+					log.Printf("tick %d: entity %d %s -> %s", event.Tick, event.Entity.Index, event.From, event.To)
 				}
-				elapsedTimeGoblin = 0
 			}
+		}
 
-			if elapsedTimeTroll >= spawnTrollRate {
-				for i := int32(0); i < spawnTrollAmount; i++ {
-					var trollEntity *Entity = createEntity()
-					var trollPosition = (rl.Vector2AddValue(spawnTrollPosition, float32(i*20)))
-					setupTroll(trollEntity, &trollPosition)
-				}
-				elapsedTimeTroll = 0
+		// :autosave
+		if world.Playback == nil && !world.GameOver && float32(world.Tick-lastAutosaveTick) >= autosaveInterval*world.Config.TickRate {
+			lastAutosaveTick = world.Tick
+			if err := saveSlots.Store(game.AUTOSAVE_SLOT, world); err != nil {
+				showNotice(fmt.Sprintf("autosave failed: %v", err))
 			}
 		}
+		camera.Target = rl.Vector2(world.RenderCameraTarget(alpha))
 
-		// :camera
-		{
-			var target rl.Vector2 = playerEntity.Position
-			var sprite = getSprite(playerEntity.SpriteId)
-			target.X = target.X + (float32(sprite.Image.Width))/2.0
-			target.Y = target.Y + (float32(sprite.Image.Height))/2.0
-			animateV2ToTarget(&camera.Target, target, delta_t, 30.0)
+		var tileCenter game.Vector2 = game.Vector2(camera.Target)
+		if playerEntity, ok := world.Get(world.Player); ok {
+			tileCenter = playerEntity.RenderPosition(alpha)
 		}
 
 		rl.BeginDrawing()
@@ -475,17 +471,12 @@ func main() {
 		// :world entities positions
 		{
 			rl.BeginMode2D(camera)
-			var mousePositionScreen rl.Vector2 = rl.GetMousePosition()
-			var mousePositionWorld rl.Vector2 = rl.GetScreenToWorld2D(mousePositionScreen, camera)
-
-			// var mouseTileX int = int(worldPositionToTilePosition(mousePositionWorld.X))
-			// var mouseTileY int = int(worldPositionToTilePosition(mousePositionWorld.Y))
 
 			// :tile rendering
 			{
 
-				var playerTileX = int32(worldPositionToTilePosition(playerEntity.Position.X))
-				var playerTileY = int32(worldPositionToTilePosition(playerEntity.Position.Y))
+				var playerTileX = int32(game.WorldPositionToTilePosition(tileCenter.X))
+				var playerTileY = int32(game.WorldPositionToTilePosition(tileCenter.Y))
 
 				const tileRadiusX int32 = 40
 				const tileRadiusY int32 = 30
@@ -494,301 +485,308 @@ func main() {
 					for y := playerTileY - tileRadiusY; y < playerTileY+tileRadiusY; y++ {
 						if (x+boolToInt(y%2 == 0))%2 == 0 {
 
-							var xPosition float32 = float32(x * tileWidth)
-							var yPosition float32 = float32(y * tileWidth)
+							var xPosition float32 = float32(x * game.TILE_WIDTH)
+							var yPosition float32 = float32(y * game.TILE_WIDTH)
 							var tileColor rl.Color = rl.White
 
-							rl.DrawRectangle(int32(xPosition)+int32(float32(tileWidth)*-0.5), int32(yPosition+(float32(tileWidth)*-0.5)), tileWidth, tileWidth, tileColor)
-						}
-					}
-				}
-
-			}
-
-			// :mouse :selector
-			{
-				var smallestDistance float32 = math.MaxFloat32
-
-				for i := 0; i < MAX_ENTITY_COUNT; i++ {
-					var en *Entity = &world.Entities[i]
-					if en.IsValid {
-						// var sprite *Sprite = getSprite(en.SpriteId)
-						var distance float32 = float32(math.Abs(float64(rl.Vector2Distance(en.Position, mousePositionWorld))))
-						if distance < entitySelectionRadius {
-							if worldFrame.SelectedEntity == nil || (distance < smallestDistance) {
-								worldFrame.SelectedEntity = en
-								smallestDistance = distance
-							}
+							rl.DrawRectangle(int32(xPosition)+int32(float32(game.TILE_WIDTH)*-0.5), int32(yPosition+(float32(game.TILE_WIDTH)*-0.5)), game.TILE_WIDTH, game.TILE_WIDTH, tileColor)
 						}
 					}
 				}
 
 			}
 
-			// :mouse :click handler
+			// :render
 			{
 
-				var top20Percent float32 = float32(screenHeight) - (float32(screenHeight) * 0.20)
+				for i := 0; i < game.MAX_ENTITY_COUNT; i++ {
+					var entity *game.Entity = &world.Entities[i]
+					if entity.IsValid {
 
-				var IsMouseButtonLeftDown bool = rl.IsMouseButtonDown(rl.MouseButtonLeft)
-				var IsMouseButtonLeftRelased bool = rl.IsMouseButtonReleased(rl.MouseButtonLeft)
-				var IsMouseButtonLeftPressed bool = rl.IsMouseButtonPressed(rl.MouseButtonLeft)
-				var selectedEntity *Entity = worldFrame.SelectedEntity
+						var entityColor rl.Color = rl.White
+						if world.Frame.SelectedEntity == entity.Handle {
+							entityColor = rl.Red
+						}
+						var position rl.Vector2 = rl.Vector2(entity.RenderPosition(alpha))
+						switch entity.Type {
 
-				if IsMouseButtonLeftDown {
-					IsMouseButtonLeftDown = false
-					if selectedEntity != nil && selectedEntity.Type == ARCH_CARD {
-						grabbedEntity = selectedEntity
-					}
+						case game.ARCH_PLAYER:
+							var sprite *Sprite = getSprite(entity.SpriteId)
+							var width float32 = float32(sprite.Image.Width)
+							var height float32 = float32(sprite.Image.Height)
+							// flashing while a hit left the player invulnerable
+							if entity.InvulnerableTimer > 0 && int32(entity.InvulnerableTimer*10)%2 == 0 {
+								entityColor = rl.Fade(rl.Color{R: 255, G: 120, B: 120, A: 255}, 0.4)
+							}
+							// the death animation, falling over and fading to red
+							var rotation float32 = 0
+							if world.DeathTimer > 0 || world.GameOver {
+								var dying float32 = 1
+								if world.DeathTimer > 0 {
+									dying = 1 - world.DeathTimer/world.Config.DeathTime
+								}
+								rotation = 90 * dying
+								entityColor = rl.Color{R: 255, G: uint8(255 * (1 - dying)), B: uint8(255 * (1 - dying)), A: uint8(255 * (1 - 0.7*dying))}
+							}
+							var source rl.Rectangle = rl.Rectangle{X: 0, Y: 0, Width: width, Height: height}
+							var destination rl.Rectangle = rl.Rectangle{X: position.X, Y: position.Y, Width: width, Height: height}
+							rl.DrawTexturePro(sprite.Image, source, destination, rl.Vector2{X: width / 2, Y: height / 2}, rotation, entityColor)
 
-					if selectedEntity != nil {
-						if mousePositionScreen.Y < top20Percent {
-							// :TODO show attack direction league like
-						}
-					}
+						case game.ARCH_ATTACK:
+							var sprite *Sprite = getSprite(entity.SpriteId)
+							if !entity.IsSwinging() {
+								rl.DrawTexture(sprite.Image, int32(position.X-float32(sprite.Image.Width/2)), int32(position.Y-float32(sprite.Image.Height/2)), entityColor)
+								break
+							}
+							// the sprite's blade points up, turned to point out of the swing
+							var width float32 = float32(sprite.Image.Width)
+							var height float32 = float32(sprite.Image.Height)
+							var source rl.Rectangle = rl.Rectangle{X: 0, Y: 0, Width: width, Height: height}
+							var destination rl.Rectangle = rl.Rectangle{X: position.X, Y: position.Y, Width: width, Height: height}
+							rl.DrawTexturePro(sprite.Image, source, destination, rl.Vector2{X: width / 2, Y: height / 2}, entity.SwingHeading()+90, entityColor)
 
-				} else if rl.IsMouseButtonPressed(rl.MouseButtonRight) {
-					/* inputAxis = rl.Vector2Subtract(terminalPoint, playerEntity.Position) */
-				}
-				if IsMouseButtonLeftPressed {
-					IsMouseButtonLeftPressed = false
-					/* var basicAttack *Entity = createEntity() */
-					/* setupAttackBasic(basicAttack) */
-					/* basicAttack.Position = playerEntity.Position */
-					/* basicAttack.inputAxis = rl.Vector2Normalize((rl.Vector2Subtract(mousePositionWorld, playerEntity.Position))) */
-					/* basicAttack.MaxPosition = rl.Vector2AddValue(playerEntity.Position, float32(basicAttack.Range)) */
-				}
+						default:
+							var sprite *Sprite = getSprite(entity.SpriteId)
+							// show collisions
+							//rl.DrawRectangle(int32(entity.CollisionRectangle.X), int32(entity.CollisionRectangle.Y), int32(entity.CollisionRectangle.Width), int32(entity.CollisionRectangle.Height), rl.Blue)
+							rl.DrawTexture(sprite.Image, int32(position.X-float32(sprite.Image.Width/2)), int32(position.Y-float32(sprite.Image.Height/2)), entityColor)
 
-				if IsMouseButtonLeftRelased {
-					IsMouseButtonLeftRelased = false
-					if grabbedEntity != nil {
-						if mousePositionScreen.Y < top20Percent {
-							// :TODO do the attack on the direction mouse poing to
-							var fireballAttack *Entity = createEntity()
-							setupAttackFireball(fireballAttack)
-							fireballAttack.Position = playerEntity.Position
-							fireballAttack.MaxPosition = rl.Vector2AddValue(playerEntity.Position, float32(fireballAttack.Range))
-							fireballAttack.inputAxis = rl.Vector2Normalize((rl.Vector2Subtract(mousePositionWorld, playerEntity.Position)))
-							destroyEntity(grabbedEntity)
 						}
-
-						grabbedEntity = nil
 					}
 
 				}
 
 			}
 
-			// :update
-			{
-				// :update grabbedEntity position
-
-				for i := 0; i < MAX_ENTITY_COUNT; i++ {
-					var entity *Entity = &world.Entities[i]
-					if entity.Type == ARCH_ATTACK {
-						//TODO MAX DISTANCE ALLOWED CONSTANT
-						if distance := rl.Vector2Distance(entity.Position, entity.MaxPosition); distance < 5 || entity.Angle >= entity.MaxAngle {
-							destroyEntity(entity)
+			// :render statuses, an icon per status over the entity under it
+			for i := 0; i < game.MAX_ENTITY_COUNT; i++ {
+				var entity *game.Entity = &world.Entities[i]
+				if entity.IsValid {
+					var position rl.Vector2 = rl.Vector2(entity.RenderPosition(alpha))
+					var sprite *Sprite = getSprite(entity.SpriteId)
+					var x int32 = int32(position.X) - 4
+					var y int32 = int32(position.Y-float32(sprite.Image.Height/2)) - 8
+					for j := 0; j < game.MAX_STATUS_COUNT; j++ {
+						var status *game.StatusEffect = &entity.Statuses[j]
+						if status.Name == "" {
 							continue
 						}
+						drawStatusIcon(world.Config.Statuses[status.Name], x, y, 7)
+						x += 8
 					}
-					// :update :positions
-
-					if entity.Type == ARCH_PLAYER {
-						entity.Position = rl.Vector2Add(entity.Position, rl.Vector2Scale(entity.inputAxis, (float32(entity.Speed)*delta_t)*runningMultiplier))
-					} else if entity.Type == ARCH_TROLL || entity.Type == ARCH_GOBLIN {
-						/* entity.inputAxis = rl.Vector2Normalize((rl.Vector2Subtract(playerEntity.Position, entity.Position))) */
-						/* entity.Position = rl.Vector2Add(entity.Position, rl.Vector2Scale(entity.inputAxis, (float32(entity.Speed)*delta_t))) */
-					} else if entity.Type == ARCH_ATTACK {
-						if entity.isMelee {
-
-							entity.Position = rl.Vector2Add(entity.Position, rl.Vector2Scale(entity.inputAxis, (float32(entity.Speed)*delta_t)))
+				}
+			}
 
-						} else {
-							entity.Position = rl.Vector2Add(entity.Position, rl.Vector2Scale(entity.inputAxis, (float32(entity.Speed)*delta_t)))
+			// :render ai, the state over every enemy and the ranges of the
+			// selected one
+			if showAI {
+				for i := 0; i < game.MAX_ENTITY_COUNT; i++ {
+					var entity *game.Entity = &world.Entities[i]
+					if entity.IsValid && entity.AI != (game.AIConfig{}) {
+						var position rl.Vector2 = rl.Vector2(entity.RenderPosition(alpha))
+						var sprite *Sprite = getSprite(entity.SpriteId)
+						rl.DrawText(entity.AIState.String(), int32(position.X)-12, int32(position.Y-float32(sprite.Image.Height/2))-10, 8, rl.DarkPurple)
+						if world.Frame.SelectedEntity == entity.Handle {
+							rl.DrawCircleLinesV(position, entity.AI.AggroRadius, rl.Purple)
+							rl.DrawCircleLinesV(position, entity.AI.AttackRange, rl.Red)
 						}
 					}
-
-					var sprite *Sprite = getSprite(entity.SpriteId)
-					entity.CollisionRectangle.X = (entity.Position.X - float32(sprite.Image.Width)/2)
-					entity.CollisionRectangle.Y = entity.Position.Y - float32(sprite.Image.Height/2)
-
-					// :update :existance
-					if entity.Health <= 0 {
-						destroyEntity(entity)
-					}
-				}
-
-				if grabbedEntity != nil {
-					grabbedEntity.Position.X = mousePositionWorld.X
-					grabbedEntity.Position.Y = mousePositionWorld.Y
 				}
 			}
 
-			// :enemy :attack
+			// :render explosions
 			{
-				for i := 0; i < MAX_ENTITY_COUNT; i++ {
-					var entity *Entity = &world.Entities[i]
-					if entity.Type == ARCH_TROLL || entity.Type == ARCH_GOBLIN {
-						if IsInRange(entity, playerEntity) {
-							// TODO enemy attacks
-							/* var sword *Entity = createEntity() */
-							/* var enemySprite = getSprite(entity.SpriteId) */
-							/* var initPosition = rl.Vector2{X: entity.Position.X + float32(enemySprite.Image.Width), Y: entity.Position.Y + float32(enemySprite.Image.Height)} */
-							/**/
-						}
-
+				var kept int = 0
+				for _, flash := range flashes {
+					rl.DrawCircleV(flash.Position, flash.Radius, rl.Fade(rl.Orange, 0.6*flash.Time/flashDuration))
+					flash.Time -= frameTime
+					if flash.Time > 0 {
+						flashes[kept] = flash
+						kept += 1
 					}
-
 				}
+				flashes = flashes[:kept]
 			}
 
-			// :collision
+			// :render damage numbers
 			{
-
-				for i := 0; i < MAX_ENTITY_COUNT; i++ {
-
-					var firstEntity *Entity = &world.Entities[i]
-					if firstEntity.Type == ARCH_ATTACK {
-
-						var didGlobalCollisionHappen bool = false
-						for j := 0; j < MAX_ENTITY_COUNT; j++ {
-
-							var didLocalCollisionHappend bool = false
-							var secondEntity *Entity = &world.Entities[j]
-							if firstEntity == secondEntity {
-								continue
-							}
-
-							if secondEntity.Type == ARCH_ATTACK || secondEntity.Type == ARCH_CARD || secondEntity.Type == ARCH_PLAYER {
-								continue
-							}
-
-							didLocalCollisionHappend = rl.CheckCollisionRecs(firstEntity.CollisionRectangle, secondEntity.CollisionRectangle)
-							if didLocalCollisionHappend {
-								secondEntity.Health -= firstEntity.Damage
-								didGlobalCollisionHappen = didLocalCollisionHappend
-							}
-
-						}
-
-						if didGlobalCollisionHappen {
-							destroyEntity(firstEntity)
-						}
+				var kept int = 0
+				for _, number := range damageNumbers {
+					var rise float32 = 12 * (1 - number.Time/damageNumberDuration)
+					rl.DrawText(number.Text, int32(number.Position.X)-3, int32(number.Position.Y-12-rise), 8, rl.Fade(number.Color, number.Time/damageNumberDuration))
+					number.Time -= frameTime
+					if number.Time > 0 {
+						damageNumbers[kept] = number
+						kept += 1
 					}
-
 				}
+				damageNumbers = damageNumbers[:kept]
 			}
 
-			// :render
-			{
-
-				var numberOfCards = 0
-				for i := 0; i < MAX_ENTITY_COUNT; i++ {
-					var entity *Entity = &world.Entities[i]
-					if entity.IsValid {
-
-						var entityColor rl.Color = rl.White
-						if worldFrame.SelectedEntity == entity {
-							entityColor = rl.Red
-						}
-						switch entity.Type {
-
-						case ARCH_CARD:
-							var sprite *Sprite = getSprite(entity.SpriteId)
-							var entityColor rl.Color = rl.White
-							if worldFrame.SelectedEntity == entity {
-								entityColor = rl.Red
-							}
-							xPosition := int32(camera.Target.X)
-							// move to bottom
-							yPosition := int32(camera.Target.Y) - (sprite.Image.Height / 2)
-
-							yPosition = yPosition + ((screenHeight / 2) / 3)
+			// :render aim preview of the grabbed card
+			if preview, ok := world.AimPreview(in.MouseWorld); ok {
+				var aimColor rl.Color = rl.Fade(rl.SkyBlue, 0.4)
+				if !preview.Valid {
+					aimColor = rl.Fade(rl.Red, 0.4)
+				}
+				var origin rl.Vector2 = rl.Vector2(preview.Origin)
+				var target rl.Vector2 = rl.Vector2(preview.Target)
+
+				switch preview.Shape {
+				case game.AIM_LINE:
+					var band rl.Rectangle = rl.Rectangle{X: origin.X, Y: origin.Y, Width: preview.Range, Height: preview.Width}
+					rl.DrawRectanglePro(band, rl.Vector2{X: 0, Y: preview.Width / 2}, preview.Angle(), aimColor)
+					if preview.Radius > 0 {
+						var end rl.Vector2 = rl.Vector2(game.Vector2Add(preview.Origin, game.Vector2Scale(game.Vector2Normalize(game.Vector2Subtract(preview.Target, preview.Origin)), preview.Range)))
+						rl.DrawCircleLinesV(end, preview.Radius, aimColor)
+					}
+				case game.AIM_CIRCLE:
+					if preview.Range > 0 {
+						rl.DrawCircleLinesV(origin, preview.Range, aimColor)
+					}
+					rl.DrawCircleV(target, preview.Width, aimColor)
+				case game.AIM_CONE:
+					var angle float32 = preview.Angle()
+					rl.DrawCircleSector(origin, preview.Range, angle-preview.Width/2, angle+preview.Width/2, 16, aimColor)
+				}
+			}
 
-							if entity == grabbedEntity {
+			rl.EndMode2D()
+		}
 
-								xPosition = int32(entity.Position.X)
-								// move to bottom
-								yPosition = int32(entity.Position.Y)
+		// :render ui hand, in screen space on top of the world. The hovered and
+		// the grabbed card are drawn last so they sit above their neighbours.
+		{
+			grabbedCard, isCardGrabbed := world.Hand.Get(world.GrabbedCard)
+			if isCardGrabbed && grabbedCard.Position.Y >= world.HandAreaTop() {
+				var top int32 = int32(world.HandAreaTop())
+				rl.DrawRectangle(0, top, screenWidth, screenHeight-top, rl.Fade(rl.DarkGray, 0.2))
+			}
 
-							} else {
-								entity.Position.X = float32(xPosition)
-								entity.Position.Y = float32(yPosition)
-							}
+			for i := 0; i < game.MAX_HAND_COUNT; i++ {
+				var entity *game.Entity = &world.Hand.Cards[i]
+				if entity.IsValid && entity.Handle != world.Frame.HoveredCard && entity.Handle != world.GrabbedCard {
+					drawCard(world, entity, alpha)
+				}
+			}
+			if hoveredCard, ok := world.Hand.Get(world.Frame.HoveredCard); ok {
+				drawCard(world, hoveredCard, alpha)
+			}
+			if isCardGrabbed {
+				drawCard(world, grabbedCard, alpha)
+			}
+		}
 
-							rl.DrawTexture(sprite.Image, xPosition-(sprite.Image.Width/2), yPosition-(sprite.Image.Height/2), entityColor)
+		// :render hud
+		if playerEntity, ok := world.Get(world.Player); ok && playerEntity.MaxMana > 0 {
+			const barWidth int32 = 100
+			var barX int32 = screenWidth - barWidth - 10
+			var fill int32 = int32(float32(barWidth) * playerEntity.Mana / float32(playerEntity.MaxMana))
+			var barColor rl.Color = rl.Blue
+			if manaFlashTime > 0 {
+				manaFlashTime -= frameTime
+				barColor = rl.Red
+			}
 
-							numberOfCards += 1
-							// do nothig for now
+			rl.DrawRectangle(barX, 10, barWidth, 8, rl.DarkGray)
+			rl.DrawRectangle(barX, 10, fill, 8, barColor)
+			rl.DrawText(fmt.Sprintf("mana %d/%d", int32(playerEntity.Mana), playerEntity.MaxMana), barX, 20, 10, rl.DarkGray)
 
-						default:
-							var sprite *Sprite = getSprite(entity.SpriteId)
-							// show collisions
-							//rl.DrawRectangle(int32(entity.CollisionRectangle.X), int32(entity.CollisionRectangle.Y), int32(entity.CollisionRectangle.Width), int32(entity.CollisionRectangle.Height), rl.Blue)
-							rl.DrawTexture(sprite.Image, int32(entity.Position.X-float32(sprite.Image.Width/2)), int32(entity.Position.Y-float32(sprite.Image.Height/2)), entityColor)
-
-						}
-					}
+			var healthFill int32 = int32(float32(barWidth) * float32(max(playerEntity.Health, 0)) / float32(max(playerEntity.MaxHealth, 1)))
+			rl.DrawRectangle(barX, 34, barWidth, 8, rl.DarkGray)
+			rl.DrawRectangle(barX, 34, min(healthFill, barWidth), 8, rl.Red)
+			var healthText string = fmt.Sprintf("health %d/%d", max(playerEntity.Health, 0), playerEntity.MaxHealth)
+			if world.Lives > 0 {
+				healthText = fmt.Sprintf("%s lives %d", healthText, world.Lives)
+			}
+			rl.DrawText(healthText, barX, 44, 10, rl.DarkGray)
 
+			var statusY int32 = 58
+			for i := 0; i < game.MAX_STATUS_COUNT; i++ {
+				var status *game.StatusEffect = &playerEntity.Statuses[i]
+				if status.Name == "" {
+					continue
 				}
-
+				drawStatusIcon(world.Config.Statuses[status.Name], barX, statusY, 10)
+				var text string = fmt.Sprintf("%s %.1fs", status.Name, status.Remaining)
+				if status.Stacks > 1 {
+					text = fmt.Sprintf("%s x%d", text, status.Stacks)
+				}
+				if status.Shield > 0 {
+					text = fmt.Sprintf("%s (%d)", text, status.Shield)
+				}
+				rl.DrawText(text, barX+14, statusY, 10, rl.DarkGray)
+				statusY += 12
 			}
+		}
 
-			// :render ui
-			{
-				for i := 0; i < MAX_HAND_COUNT; i++ {
-					var entity *Entity = &hand.Cards[i]
-					if entity.IsValid {
-						switch entity.Type {
-						default:
-
-						}
-					}
+		// :render debug
+		{
+			var stats game.AllocatorStats = world.EntityStats()
+			var text string = fmt.Sprintf("entities %d/%d peak %d refused %d", stats.Live, stats.Capacity, stats.HighWaterMark, stats.Refused)
+			rl.DrawText(text, 10, 10, 10, rl.DarkGray)
+			rl.DrawText(fmt.Sprintf("seed %d", world.Config.Seed), 10, 22, 10, rl.DarkGray)
+			var handStats game.AllocatorStats = world.Hand.Stats()
+			var deckText string = fmt.Sprintf("hand %d/%d draw %d discard %d", handStats.Live, world.Config.HandSize, len(world.Deck.DrawPile), len(world.Deck.DiscardPile))
+			rl.DrawText(deckText, 10, 46, 10, rl.DarkGray)
+
+			if world.Playback != nil {
+				var playback *game.ReplayPlayer = world.Playback
+				var text string = fmt.Sprintf("replay tick %d/%d", playback.Next, len(playback.Replay.Frames))
+				var textColor rl.Color = rl.DarkGray
+				if playback.DivergedTick != 0 {
+					text = fmt.Sprintf("%s diverged at tick %d", text, playback.DivergedTick)
+					textColor = rl.Red
+				}
+				rl.DrawText(text, 10, 34, 10, textColor)
+			}
 
+			var errorY int32 = screenHeight - 34
+			for _, path := range []string{archetypesPath, spawnsPath, cardsPath, statusesPath} {
+				if err, ok := dataErrors[path]; ok {
+					rl.DrawText(err.Error(), 10, errorY, 10, rl.Red)
+					errorY -= 12
 				}
+			}
 
+			if noticeTime > 0 {
+				noticeTime -= frameTime
+				rl.DrawText(notice, 10, screenHeight-20, 10, rl.DarkGray)
 			}
+		}
 
-			rl.EndMode2D()
+		// :render game over
+		if world.GameOver {
+			rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.Fade(rl.Black, 0.6))
+			var title string = "GAME OVER"
+			rl.DrawText(title, screenWidth/2-rl.MeasureText(title, 40)/2, screenHeight/2-90, 40, rl.Red)
+
+			var stats game.RunStats = world.Stats
+			var lines []string = []string{
+				fmt.Sprintf("survived %.1fs", world.Survived()),
+				fmt.Sprintf("kills %d", stats.Kills),
+				fmt.Sprintf("deaths %d", stats.Deaths),
+				fmt.Sprintf("cards played %d", stats.CardsPlayed),
+				fmt.Sprintf("damage dealt %d taken %d", stats.DamageDealt, stats.DamageTaken),
+				"",
+				"press R to start a new run",
+			}
+			for i, line := range lines {
+				rl.DrawText(line, screenWidth/2-rl.MeasureText(line, 20)/2, screenHeight/2-30+int32(i)*22, 20, rl.RayWhite)
+			}
 		}
 
 		rl.EndDrawing()
 	}
 
-	rl.UnloadTexture(sprites[SPRITE_TROLL].Image)
-	rl.UnloadTexture(sprites[SPRITE_PLAYER].Image)
-	rl.UnloadTexture(sprites[SPRITE_GOBLIN].Image)
+	if world.Recorder != nil {
+		if err := world.Recorder.Close(); err != nil {
+			log.Printf("writing replay: %v", err)
+		}
+	}
 
-}
+	for id := game.SPRITE_NIL + 1; int(id) < len(sprites); id++ {
+		rl.UnloadTexture(sprites[id].Image)
+	}
 
-// :TODO  BACK BURNNERS this is and idea on how to implement melee but have to remember to add the "parent" position entity aka the entity doing the attack
-// for now i want to focus on making the cards that the player is going to use
-
-/* var swordAttack *Entity = createEntity() */
-/**/
-/* // Calculate direction vector */
-/* deltaX := float64(mousePositionWorld.X - playerEntity.Position.X) */
-/* deltaY := float64(mousePositionWorld.Y - playerEntity.Position.Y) */
-/**/
-/* // Calculate angle using arctangent */
-/* angle := float32(math.Atan2(deltaY, deltaX)) */
-/**/
-/* swordAttack.Angle = angle */
-/* setupAttackSword(swordAttack, playerEntity.Position, swordAttack.MaxPosition) */
-/* var playerSprite *Sprite = getSprite(playerEntity.SpriteId) */
-/* var swingDirectionStart rl.Vector2 = rl.Vector2Normalize(rl.NewVector2(float32(math.Cos(float64(angle))), float32(math.Sin(float64(angle))))) */
-/* swordAttack.inputAxis = rl.Vector2{X: swingDirectionStart.Y, Y: -swingDirectionStart.X} */
-/**/
-/* swordAttack.CenterPosition = rl.Vector2{ */
-/*   X: (playerEntity.Position.X + swingDirectionStart.X*float32(playerSprite.Image.Width)), */
-/*   Y: playerEntity.Position.Y + swingDirectionStart.Y*float32(playerSprite.Image.Height)} */
-/**/
-/* swordAttack.Position = rl.Vector2{ */
-/*   X: (playerEntity.Position.X + swingDirectionStart.X*float32(playerSprite.Image.Width)) + (-1 * swordAttack.inputAxis.X * 10), */
-/*   Y: playerEntity.Position.Y + swingDirectionStart.Y*float32(playerSprite.Image.Height) + (-1 * swordAttack.inputAxis.Y * 10)} */
-/**/
-/* swordAttack.Radius = float32(math.Sqrt(math.Pow(float64(swordAttack.Position.X-swordAttack.CenterPosition.X), 2) + math.Pow(float64(swordAttack.Position.Y-swordAttack.CenterPosition.Y), 2))) */
-/**/
-/* swordAttack.MaxPosition = rl.Vector2Add(swordAttack.Position, rl.Vector2Scale(swordAttack.inputAxis, float32(swordAttack.Range))) */
-/**/
+}